@@ -0,0 +1,125 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+)
+
+// AckPolicy selects when the delivery of a job is acknowledged in list and
+// stream mode.
+type AckPolicy int
+
+const (
+	// AckAfterSuccess acknowledges a job once it succeeded, and moves it to
+	// the dead letter queue once it failed all its attempts.
+	AckAfterSuccess AckPolicy = iota
+	// AckBeforeRun acknowledges a job before running it. Jobs are run at
+	// most once and failed jobs are dropped.
+	AckBeforeRun
+	// AckManual leaves the acknowledgement to the handler, which calls Ack
+	// once its side effects are committed. Jobs that succeed without being
+	// acknowledged stay pending and are delivered again when recovered,
+	// jobs that fail all their attempts go to the dead letter queue.
+	AckManual
+)
+
+// ErrNoDelivery is returned by Ack when ctx does not come from a job run by
+// a worker.
+var ErrNoDelivery = errors.New("redisdb: no message to acknowledge")
+
+var errNacked = errors.New("redisdb: message rejected for redelivery")
+
+type ackKey struct{}
+
+// Acknowledger settles the message of a running job from its handler.
+type Acknowledger struct {
+	w      *Worker
+	m      *job.Message
+	nacked atomic.Bool
+	// slots are the semaphore slots held by the job, see ExtendLease.
+	slots []heldSlot
+}
+
+// AckFromContext returns the acknowledger of the job running with ctx. Its
+// methods return ErrNoDelivery when ctx does not come from a worker.
+func AckFromContext(ctx context.Context) *Acknowledger {
+	a, _ := ctx.Value(ackKey{}).(*Acknowledger)
+	return a
+}
+
+// Ack acknowledges the message of the running job, for instance before a
+// long post-processing. Calling it again, or after the message was
+// settled, does nothing. With AckManual, it also queues the next job of
+// the chain of the job, see QueueChain.
+func (a *Acknowledger) Ack() error {
+	if a == nil || a.m == nil {
+		return ErrNoDelivery
+	}
+	if a.w.opts.ackPolicy == AckManual {
+		if v, ok := a.w.deliveries.Load(a.m); ok && len(v.(*delivery).env.Next) > 0 {
+			return a.w.ackNext(a.m)
+		}
+	}
+	return a.w.finish(a.m, nil)
+}
+
+// Nack rejects the message of the running job so that it is delivered
+// again after delay, right away when delay is zero. The job is then not
+// retried by the queue, whatever the handler returns.
+func (a *Acknowledger) Nack(delay time.Duration) error {
+	if a == nil || a.m == nil {
+		return ErrNoDelivery
+	}
+	v, ok := a.w.deliveries.LoadAndDelete(a.m)
+	if !ok {
+		return nil
+	}
+	a.nacked.Store(true)
+
+	d := v.(*delivery)
+	defer a.w.unlock(d.lock)
+	return a.w.redeliver(d, delay)
+}
+
+// Ack acknowledges the message of the running job, see Acknowledger.Ack.
+func Ack(ctx context.Context) error {
+	return AckFromContext(ctx).Ack()
+}
+
+// finish acknowledges the delivery of a finished job, or rejects it when
+// the job failed.
+func (w *Worker) finish(m *job.Message, runErr error) error {
+	v, ok := w.deliveries.LoadAndDelete(m)
+	if !ok {
+		return nil
+	}
+
+	d := v.(*delivery)
+	defer w.unlock(d.lock)
+	ctx := context.Background()
+	if runErr == nil {
+		err := w.broker.ack(ctx, d)
+		if err == nil {
+			w.metrics.recordSettled(ctx, d.channel, true)
+			w.dropJSONPayload(ctx, d.env)
+		}
+		return err
+	}
+	err := w.broker.reject(ctx, d)
+	if err == nil {
+		w.metrics.recordSettled(ctx, d.channel, false)
+	}
+	return err
+}
+
+// forget stops tracking the delivery of a job without settling it, so it
+// is delivered again once recovered.
+func (w *Worker) forget(m *job.Message) {
+	if v, ok := w.deliveries.LoadAndDelete(m); ok {
+		w.unlock(v.(*delivery).lock)
+	}
+}
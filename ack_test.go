@@ -0,0 +1,123 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestAckManual(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("ack-manual"),
+		WithDeliveryMode(Stream),
+		WithAckPolicy(AckManual),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			if string(m.Payload()) == "commit" {
+				return Ack(ctx)
+			}
+			return nil
+		}),
+	)
+
+	for _, v := range []string{"commit", "skip"} {
+		m := job.NewMessage(mockMessage{Message: v})
+		assert.NoError(t, w.Queue(&m))
+	}
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// the job that was not acknowledged is still pending
+	pending := w.Redis().XPending(ctx, "ack-manual", defaultConsumerGroup).Val()
+	assert.Equal(t, int64(1), pending.Count)
+	assert.Equal(t, 0, w.inflight())
+
+	assert.Equal(t, ErrNoDelivery, Ack(ctx))
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestAckBeforeRun(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("ack-before"),
+		WithDeliveryMode(List),
+		WithConsumerName("before"),
+		WithAckPolicy(AckBeforeRun),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			return errors.New("failed")
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.Queue(&m))
+	task, err := w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.Error(t, w.Run(ctx, task))
+
+	// the failed job is dropped
+	assert.Equal(t, int64(0), w.Redis().LLen(ctx, processingKey("ack-before", "before")).Val())
+	assert.Equal(t, int64(0), w.Redis().XLen(ctx, deadKey("ack-before")).Val())
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestNack(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	attempts := 0
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("nack"),
+		WithDeliveryMode(List),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			attempts++
+			if attempts == 1 {
+				assert.NoError(t, AckFromContext(ctx).Nack(time.Second))
+				return errors.New("busy")
+			}
+			return nil
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{
+		RetryCount: job.Int64(3),
+	})
+	assert.NoError(t, w.Queue(&m))
+
+	// the rejected job is not retried right away
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, int64(1), w.Redis().ZCard(ctx, delayedKey("nack")).Val())
+
+	assert.Eventually(t, func() bool {
+		return w.Redis().LLen(ctx, "nack").Val() == 1
+	}, 3*time.Second, 100*time.Millisecond)
+
+	n, err = w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, int64(0), w.Redis().XLen(ctx, deadKey("nack")).Val())
+
+	assert.Equal(t, ErrNoDelivery, AckFromContext(ctx).Nack(0))
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,103 @@
+package redisdb
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultAckFlushInterval = 10 * time.Millisecond
+	defaultAckBatchSize     = 100
+)
+
+// ackBatcher gathers the acknowledgements of stream entries and sends them
+// in one XACK per stream, once the flush interval elapsed or the batch is
+// full. Acks waiting for their batch are lost if the process dies, the
+// entries are then delivered again like unacknowledged ones.
+type ackBatcher struct {
+	rdb      redis.Cmdable
+	group    string
+	log      *slog.Logger
+	interval time.Duration
+	size     int
+
+	mu    sync.Mutex
+	ids   map[string][]string
+	n     int
+	timer *time.Timer
+}
+
+func newAckBatcher(w *Worker, group string) *ackBatcher {
+	a := &ackBatcher{
+		rdb:      w.rdb,
+		group:    group,
+		log:      w.log,
+		interval: w.opts.ackFlushInterval,
+		size:     w.opts.ackBatchSize,
+		ids:      make(map[string][]string),
+	}
+	if a.interval <= 0 {
+		a.interval = defaultAckFlushInterval
+	}
+	if a.size <= 0 {
+		a.size = defaultAckBatchSize
+	}
+	return a
+}
+
+// add queues the ack of an entry. A full batch is sent right away and
+// its error returned.
+func (a *ackBatcher) add(ctx context.Context, channel, id string) error {
+	a.mu.Lock()
+	a.ids[channel] = append(a.ids[channel], id)
+	a.n++
+	if a.n >= a.size {
+		ids := a.take()
+		a.mu.Unlock()
+		return a.write(ctx, ids)
+	}
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.interval, a.flush)
+	}
+	a.mu.Unlock()
+	return nil
+}
+
+// take returns the pending acks, the caller holds the lock.
+func (a *ackBatcher) take() map[string][]string {
+	ids := a.ids
+	a.ids = make(map[string][]string)
+	a.n = 0
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	return ids
+}
+
+// flush sends the pending acks.
+func (a *ackBatcher) flush() {
+	a.mu.Lock()
+	ids := a.take()
+	a.mu.Unlock()
+	if err := a.write(context.Background(), ids); err != nil {
+		a.log.Error("failed to acknowledge messages", "error", err)
+	}
+}
+
+func (a *ackBatcher) write(ctx context.Context, ids map[string][]string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := a.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for channel, ids := range ids {
+			pipe.XAck(ctx, channel, a.group, ids...)
+		}
+		return nil
+	})
+	return err
+}
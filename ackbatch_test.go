@@ -0,0 +1,51 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestAckBatch(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("acked"),
+		WithDeliveryMode(Stream),
+		WithAckFlushInterval(time.Hour),
+		WithAckBatchSize(3),
+	)
+	rdb := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer rdb.Close()
+	pending := func() int64 {
+		return rdb.XPending(ctx, "acked", defaultConsumerGroup).Val().Count
+	}
+
+	for i := 0; i < 4; i++ {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		require.NoError(t, w.Queue(&m))
+	}
+	for i := 0; i < 4; i++ {
+		msg, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		assert.NoError(t, w.Run(ctx, msg))
+		if i == 1 {
+			// the acks wait for their batch
+			assert.Equal(t, int64(2), pending())
+		}
+	}
+	assert.Equal(t, int64(1), pending())
+
+	// and the last ones are sent on shutdown
+	assert.NoError(t, w.Shutdown())
+	assert.Zero(t, pending())
+}
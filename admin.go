@@ -0,0 +1,88 @@
+package redisdb
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// adminListLimit is the default number of jobs listed by AdminHandler.
+const adminListLimit = 100
+
+// AdminHandler returns an http.Handler serving the operations of a worker
+// as JSON, to be mounted in an existing server with http.StripPrefix:
+//
+//	GET  /stats              Worker.Stats
+//	GET  /topology           Worker.Topology
+//	GET  /jobs?state=&limit= the jobs in a state, pending by default
+//	GET  /jobs/{id}          a job by ID
+//	GET  /jobs/{id}/failure  the failed runs of a job
+//	GET  /dead?limit=        the dead jobs
+//	POST /dead/retry?limit=  Worker.RequeueDead
+//	POST /purge              Worker.Purge
+//
+// The handler does no authentication.
+func AdminHandler(w *Worker) http.Handler {
+	i := &Inspector{w: w}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /stats", func(rw http.ResponseWriter, r *http.Request) {
+		s, err := w.Stats(r.Context())
+		writeJSON(rw, s, err)
+	})
+	mux.HandleFunc("GET /topology", func(rw http.ResponseWriter, r *http.Request) {
+		writeJSON(rw, w.Topology(), nil)
+	})
+	mux.HandleFunc("GET /jobs", func(rw http.ResponseWriter, r *http.Request) {
+		state := JobState(r.URL.Query().Get("state"))
+		if state == "" {
+			state = JobPending
+		}
+		jobs, err := i.List(r.Context(), state, queryLimit(r))
+		writeJSON(rw, jobs, err)
+	})
+	mux.HandleFunc("GET /jobs/{id}", func(rw http.ResponseWriter, r *http.Request) {
+		j, err := i.Job(r.Context(), r.PathValue("id"))
+		writeJSON(rw, j, err)
+	})
+	mux.HandleFunc("GET /jobs/{id}/failure", func(rw http.ResponseWriter, r *http.Request) {
+		f, err := i.Failure(r.Context(), r.PathValue("id"))
+		writeJSON(rw, f, err)
+	})
+	mux.HandleFunc("GET /dead", func(rw http.ResponseWriter, r *http.Request) {
+		jobs, err := i.List(r.Context(), JobDead, queryLimit(r))
+		writeJSON(rw, jobs, err)
+	})
+	mux.HandleFunc("POST /dead/retry", func(rw http.ResponseWriter, r *http.Request) {
+		n, err := w.RequeueDead(r.Context(), queryLimit(r))
+		writeJSON(rw, map[string]int64{"requeued": n}, err)
+	})
+	mux.HandleFunc("POST /purge", func(rw http.ResponseWriter, r *http.Request) {
+		n, err := w.Purge(r.Context())
+		writeJSON(rw, map[string]int64{"purged": n}, err)
+	})
+
+	return mux
+}
+
+func queryLimit(r *http.Request) int {
+	n, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || n <= 0 {
+		return adminListLimit
+	}
+	return n
+}
+
+func writeJSON(rw http.ResponseWriter, v any, err error) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrJobNotFound) {
+			status = http.StatusNotFound
+		}
+		rw.WriteHeader(status)
+		v = map[string]string{"error": err.Error()}
+	}
+	_ = json.NewEncoder(rw).Encode(v)
+}
@@ -0,0 +1,85 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestAdminHandler(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "admin"
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+	)
+	defer w.Shutdown()
+	srv := httptest.NewServer(http.StripPrefix("/admin", AdminHandler(w)))
+	defer srv.Close()
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	id, err := w.QueueWithID(ctx, &m)
+	require.NoError(t, err)
+
+	get := func(path string, v any) int {
+		res, err := http.Get(srv.URL + "/admin" + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.NoError(t, json.NewDecoder(res.Body).Decode(v))
+		return res.StatusCode
+	}
+
+	var stats Stats
+	assert.Equal(t, http.StatusOK, get("/stats", &stats))
+	require.Len(t, stats.Channels, 1)
+	assert.Equal(t, int64(1), stats.Channels[0].Jobs[JobPending])
+
+	var topology Topology
+	assert.Equal(t, http.StatusOK, get("/topology", &topology))
+	assert.Equal(t, []string{channel}, topology.Channels)
+	assert.Equal(t, "none", topology.Retry.Jitter)
+
+	var j JobDetails
+	assert.Equal(t, http.StatusOK, get("/jobs/"+id, &j))
+	assert.Equal(t, id, j.ID)
+	assert.Equal(t, JobPending, j.State)
+	var e map[string]string
+	assert.Equal(t, http.StatusNotFound, get("/jobs/missing", &e))
+
+	data, err := w.peekList(ctx, channel, 1)
+	require.NoError(t, err)
+	require.NoError(t, w.Redis().XAdd(ctx, &redis.XAddArgs{
+		Stream: deadKey(channel),
+		Values: map[string]any{streamPayloadField: data[0]},
+	}).Err())
+	var dead []JobDetails
+	assert.Equal(t, http.StatusOK, get("/dead", &dead))
+	require.Len(t, dead, 1)
+	assert.Equal(t, id, dead[0].ID)
+
+	res, err := http.Post(srv.URL+"/admin/dead/retry", "", nil)
+	require.NoError(t, err)
+	var retried map[string]int64
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&retried))
+	res.Body.Close()
+	assert.Equal(t, int64(1), retried["requeued"])
+
+	res, err = http.Post(srv.URL+"/admin/purge", "", nil)
+	require.NoError(t, err)
+	var purged map[string]int64
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&purged))
+	res.Body.Close()
+	assert.Equal(t, int64(2), purged["purged"])
+}
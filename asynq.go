@@ -0,0 +1,604 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/job"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var _ broker = (*asynqBroker)(nil)
+
+// AsynqTypeHeader is the metadata header holding the type of asynq tasks,
+// see ContextWithMetadata. Jobs queued without it are given the channel
+// name as type.
+const AsynqTypeHeader = "asynq-type"
+
+const (
+	asynqQueuesKey = "asynq:queues"
+	// asynqLeaseDuration is how long a task stays active without its lease
+	// being extended before it is handed back to the queue.
+	asynqLeaseDuration = 30 * time.Second
+	// asynqPollInterval is how often leases are extended and the due
+	// scheduled and retry tasks are moved to their queue.
+	asynqPollInterval = time.Second
+	// asynqDefaultTimeout applies to tasks queued without timeout nor
+	// deadline, like asynq does.
+	asynqDefaultTimeout = 30 * time.Minute
+	asynqStatsTTL       = 90 * 24 * time.Hour
+	asynqArchivedMax    = 10000
+	asynqArchivedTTL    = 90 * 24 * time.Hour
+	asynqForwardBatch   = 100
+)
+
+func asynqKey(queue, name string) string {
+	return "asynq:{" + queue + "}:" + name
+}
+
+func asynqTaskKey(queue, id string) string {
+	return asynqKey(queue, "t:"+id)
+}
+
+func asynqStatsKey(queue, name string, t time.Time) string {
+	return asynqKey(queue, name+":"+t.UTC().Format(time.DateOnly))
+}
+
+// asynqDequeueScript moves the next task of the pending list KEYS[1] to
+// the active list KEYS[2] unless the queue is paused (KEYS[4]), leases it
+// until ARGV[1] in KEYS[3] and returns its ID and message. Like the other
+// scripts reading task hashes by ID, its first line tells Dragonfly that
+// it touches keys it is not passed, Redis reads it as a comment.
+var asynqDequeueScript = redis.NewScript(`--!df flags=allow-undeclared-keys
+if redis.call('EXISTS', KEYS[4]) == 1 then
+  return nil
+end
+local id = redis.call('RPOPLPUSH', KEYS[1], KEYS[2])
+if not id then
+  return nil
+end
+local key = ARGV[2] .. id
+redis.call('HSET', key, 'state', 'active')
+redis.call('HDEL', key, 'pending_since')
+redis.call('ZADD', KEYS[3], ARGV[1], id)
+return {id, redis.call('HGET', key, 'msg')}
+`)
+
+// asynqDoneScript removes the handled task ARGV[1] and counts it as
+// processed. KEYS[5] is its uniqueness lock, released if still held.
+var asynqDoneScript = redis.NewScript(`
+redis.call('LREM', KEYS[1], 0, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('DEL', KEYS[3])
+if redis.call('INCR', KEYS[4]) == 1 then
+  redis.call('EXPIRE', KEYS[4], ARGV[2])
+end
+if KEYS[5] ~= '' and redis.call('GET', KEYS[5]) == ARGV[1] then
+  redis.call('DEL', KEYS[5])
+end
+redis.call('INCR', KEYS[6])
+return 1
+`)
+
+// asynqArchiveScript moves the failed task ARGV[1] to the archived set
+// KEYS[3], trimmed like asynq does, and counts it as processed and failed.
+var asynqArchiveScript = redis.NewScript(`
+redis.call('LREM', KEYS[1], 0, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+redis.call('ZREMRANGEBYSCORE', KEYS[3], '-inf', ARGV[4])
+redis.call('ZREMRANGEBYRANK', KEYS[3], 0, -tonumber(ARGV[5]) - 1)
+redis.call('HSET', KEYS[4], 'msg', ARGV[2], 'state', 'archived')
+for i = 5, 6 do
+  if redis.call('INCR', KEYS[i]) == 1 then
+    redis.call('EXPIRE', KEYS[i], ARGV[6])
+  end
+end
+redis.call('INCR', KEYS[7])
+redis.call('INCR', KEYS[8])
+return 1
+`)
+
+// asynqRetryScript moves the active task ARGV[1] to the retry set KEYS[3],
+// to run again at ARGV[3].
+var asynqRetryScript = redis.NewScript(`
+redis.call('LREM', KEYS[1], 0, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+redis.call('HSET', KEYS[4], 'msg', ARGV[2], 'state', 'retry')
+return 1
+`)
+
+// asynqRequeueScript hands the active task ARGV[1] back to the end of the
+// pending list KEYS[3], so it is the next one to be taken.
+var asynqRequeueScript = redis.NewScript(`
+redis.call('LREM', KEYS[1], 0, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('RPUSH', KEYS[3], ARGV[1])
+redis.call('HSET', KEYS[4], 'msg', ARGV[2], 'state', 'pending', 'pending_since', ARGV[3])
+return 1
+`)
+
+// asynqForwardScript moves up to ARGV[2] tasks of the scheduled or retry
+// set KEYS[1] that are due at ARGV[1] to the pending list KEYS[2].
+var asynqForwardScript = redis.NewScript(`--!df flags=allow-undeclared-keys
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, id in ipairs(ids) do
+  redis.call('LPUSH', KEYS[2], id)
+  redis.call('ZREM', KEYS[1], id)
+  redis.call('HSET', ARGV[3] .. id, 'state', 'pending', 'pending_since', ARGV[4])
+end
+return #ids
+`)
+
+// asynqRecoverScript hands the tasks whose lease in KEYS[1] expired before
+// ARGV[1] back to the pending list KEYS[3].
+var asynqRecoverScript = redis.NewScript(`--!df flags=allow-undeclared-keys
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, id in ipairs(ids) do
+  redis.call('LREM', KEYS[2], 0, id)
+  redis.call('ZREM', KEYS[1], id)
+  redis.call('RPUSH', KEYS[3], id)
+  redis.call('HSET', ARGV[3] .. id, 'state', 'pending', 'pending_since', ARGV[4])
+end
+return #ids
+`)
+
+// asynqTask is the TaskMessage protobuf message of asynq.
+type asynqTask struct {
+	Type         string
+	Payload      []byte
+	ID           string
+	Queue        string
+	Retry        int32
+	Retried      int32
+	ErrorMsg     string
+	Timeout      int64
+	Deadline     int64
+	UniqueKey    string
+	Retention    int64
+	CompletedAt  int64
+	LastFailedAt int64
+	GroupKey     string
+}
+
+func (t *asynqTask) marshal() []byte {
+	var b []byte
+	appendString := func(num protowire.Number, v string) {
+		if v != "" {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendString(b, v)
+		}
+	}
+	appendInt := func(num protowire.Number, v int64) {
+		if v != 0 {
+			b = protowire.AppendTag(b, num, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(v))
+		}
+	}
+	appendString(1, t.Type)
+	if len(t.Payload) > 0 {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, t.Payload)
+	}
+	appendString(3, t.ID)
+	appendString(4, t.Queue)
+	appendInt(5, int64(t.Retry))
+	appendInt(6, int64(t.Retried))
+	appendString(7, t.ErrorMsg)
+	appendInt(8, t.Timeout)
+	appendInt(9, t.Deadline)
+	appendString(10, t.UniqueKey)
+	appendInt(11, t.LastFailedAt)
+	appendInt(12, t.Retention)
+	appendInt(13, t.CompletedAt)
+	appendString(14, t.GroupKey)
+	return b
+}
+
+func parseAsynqTask(b []byte) (*asynqTask, error) {
+	t := &asynqTask{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			switch num {
+			case 5:
+				t.Retry = int32(v)
+			case 6:
+				t.Retried = int32(v)
+			case 8:
+				t.Timeout = int64(v)
+			case 9:
+				t.Deadline = int64(v)
+			case 11:
+				t.LastFailedAt = int64(v)
+			case 12:
+				t.Retention = int64(v)
+			case 13:
+				t.CompletedAt = int64(v)
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			switch num {
+			case 1:
+				t.Type = string(v)
+			case 2:
+				t.Payload = append([]byte(nil), v...)
+			case 3:
+				t.ID = string(v)
+			case 4:
+				t.Queue = string(v)
+			case 7:
+				t.ErrorMsg = string(v)
+			case 10:
+				t.UniqueKey = string(v)
+			case 14:
+				t.GroupKey = string(v)
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return t, nil
+}
+
+// asynqBroker reads and writes the queues of hibiken/asynq, so that asynq
+// servers and workers of this package can drain the same queues. Tasks
+// are leased while they run and the due scheduled and retry tasks are
+// forwarded to their queue, like an asynq server does. Task retention and
+// aggregation groups are not supported.
+type asynqBroker struct {
+	w        *Worker
+	rdb      redis.Cmdable
+	channels *channelSet
+	log      *slog.Logger
+
+	// active holds the tasks being processed by ID, to extend their lease.
+	mu     sync.Mutex
+	active map[string]*asynqTask
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newAsynqBroker(ctx context.Context, w *Worker) (*asynqBroker, error) {
+	b := &asynqBroker{
+		w:        w,
+		rdb:      w.rdb,
+		channels: newChannelSet(w.opts.channels, w.opts.channelStrategy, nil),
+		log:      w.log,
+		active:   make(map[string]*asynqTask),
+		stop:     make(chan struct{}),
+	}
+
+	if err := b.rdb.SAdd(ctx, asynqQueuesKey, b.channels.names).Err(); err != nil {
+		return nil, err
+	}
+	if err := b.forward(ctx); err != nil {
+		return nil, err
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(asynqPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				ctx := context.Background()
+				if err := b.extendLeases(ctx); err != nil {
+					b.log.Error("failed to extend asynq leases", "error", err)
+				}
+				if err := b.forward(ctx); err != nil {
+					b.log.Error("failed to forward asynq tasks", "error", err)
+				}
+			}
+		}
+	}()
+
+	return b, nil
+}
+
+// forward moves the due scheduled and retry tasks to their queue, and the
+// tasks whose lease expired back to it.
+func (b *asynqBroker) forward(ctx context.Context) error {
+	now := time.Now()
+	since := now.UnixNano()
+	for _, channel := range b.channels.names {
+		pending := asynqKey(channel, "pending")
+		prefix := asynqTaskKey(channel, "")
+		for _, set := range []string{"scheduled", "retry"} {
+			err := asynqForwardScript.Run(ctx, b.rdb,
+				[]string{asynqKey(channel, set), pending},
+				now.Unix(), asynqForwardBatch, prefix, since,
+			).Err()
+			if err != nil {
+				return err
+			}
+		}
+		err := asynqRecoverScript.Run(ctx, b.rdb,
+			[]string{asynqKey(channel, "lease"), asynqKey(channel, "active"), pending},
+			now.Unix(), asynqForwardBatch, prefix, since,
+		).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *asynqBroker) extendLeases(ctx context.Context) error {
+	expiry := float64(time.Now().Add(asynqLeaseDuration).Unix())
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.active) == 0 {
+		return nil
+	}
+	_, err := b.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for id, t := range b.active {
+			pipe.ZAddXX(ctx, asynqKey(t.Queue, "lease"), redis.Z{Score: expiry, Member: id})
+		}
+		return nil
+	})
+	return err
+}
+
+// push queues the job of data as an asynq task, through a transaction
+// unless rdb already is a pipeline.
+func (b *asynqBroker) push(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error {
+	m, env, err := b.w.decode(data)
+	if err != nil {
+		return err
+	}
+	t := &asynqTask{
+		Type:    env.Headers[AsynqTypeHeader],
+		Payload: m.Body,
+		ID:      env.ID,
+		Queue:   channel,
+		Retry:   int32(m.RetryCount),
+		Timeout: int64((m.Timeout + time.Second - 1) / time.Second),
+	}
+	if t.Type == "" {
+		t.Type = channel
+	}
+	if t.ID == "" {
+		t.ID = ulid.Make().String()
+	}
+
+	enqueue := func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, asynqQueuesKey, channel)
+		pipe.HSet(ctx, asynqTaskKey(channel, t.ID),
+			"msg", t.marshal(), "state", "pending", "pending_since", time.Now().UnixNano())
+		pipe.LPush(ctx, asynqKey(channel, "pending"), t.ID)
+		return nil
+	}
+	if pipe, ok := rdb.(redis.Pipeliner); ok {
+		return enqueue(pipe)
+	}
+	_, err = rdb.TxPipelined(ctx, enqueue)
+	return err
+}
+
+func (b *asynqBroker) pop(ctx context.Context, timeout time.Duration) (*delivery, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, channel := range b.channels.next() {
+			d, err := b.dequeue(ctx, channel)
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			return d, nil
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, queue.ErrNoTaskInQueue
+		}
+		if wait > listPollInterval {
+			wait = listPollInterval
+		}
+		select {
+		case <-b.stop:
+			return nil, queue.ErrQueueHasBeenClosed
+		case <-ctx.Done():
+			return nil, queue.ErrNoTaskInQueue
+		case <-time.After(wait):
+		}
+	}
+}
+
+// dequeue takes the next task of channel and converts it to a job.
+func (b *asynqBroker) dequeue(ctx context.Context, channel string) (*delivery, error) {
+	res, err := asynqDequeueScript.Run(ctx, b.rdb,
+		[]string{
+			asynqKey(channel, "pending"),
+			asynqKey(channel, "active"),
+			asynqKey(channel, "lease"),
+			asynqKey(channel, "paused"),
+		},
+		time.Now().Add(asynqLeaseDuration).Unix(), asynqTaskKey(channel, ""),
+	).Slice()
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res[0].(string)
+	msg, _ := res[1].(string)
+	t, err := parseAsynqTask([]byte(msg))
+	if err != nil {
+		return nil, fmt.Errorf("redisdb: malformed asynq task %s: %w", id, err)
+	}
+	t.ID = id
+	t.Queue = channel
+
+	m := &job.Message{
+		Body:       t.Payload,
+		RetryCount: int64(max(t.Retry-t.Retried, 0)),
+		Timeout:    time.Duration(t.Timeout) * time.Second,
+	}
+	if t.Timeout == 0 {
+		m.Timeout = asynqDefaultTimeout
+		if t.Deadline > 0 {
+			m.Timeout = time.Until(time.Unix(t.Deadline, 0))
+		}
+	}
+	data, err := b.w.encode(m, envelope{
+		Version:  envelopeVersion,
+		ID:       id,
+		Attempts: int(t.Retried),
+		Headers:  map[string]string{AsynqTypeHeader: t.Type},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	b.active[id] = t
+	b.mu.Unlock()
+	return &delivery{channel: channel, id: id, data: data}, nil
+}
+
+// release stops tracking the task of a settled delivery and returns it
+// with the runs of the delivery counted as retries.
+func (b *asynqBroker) release(ctx context.Context, d *delivery) (*asynqTask, error) {
+	b.mu.Lock()
+	t, ok := b.active[d.id]
+	delete(b.active, d.id)
+	b.mu.Unlock()
+	if !ok {
+		msg, err := b.rdb.HGet(ctx, asynqTaskKey(d.channel, d.id), "msg").Bytes()
+		if err != nil {
+			return nil, err
+		}
+		if t, err = parseAsynqTask(msg); err != nil {
+			return nil, err
+		}
+	}
+	t.Retried += atomic.LoadInt32(&d.attempts)
+	return t, nil
+}
+
+func (b *asynqBroker) ack(ctx context.Context, d *delivery) error {
+	t, err := b.release(ctx, d)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return asynqDoneScript.Run(ctx, b.rdb,
+		[]string{
+			asynqKey(d.channel, "active"),
+			asynqKey(d.channel, "lease"),
+			asynqTaskKey(d.channel, d.id),
+			asynqStatsKey(d.channel, "processed", now),
+			t.UniqueKey,
+			asynqKey(d.channel, "processed"),
+		},
+		d.id, int64(asynqStatsTTL/time.Second),
+	).Err()
+}
+
+// reject archives a task that failed all its attempts.
+func (b *asynqBroker) reject(ctx context.Context, d *delivery) error {
+	t, err := b.release(ctx, d)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	t.LastFailedAt = now.Unix()
+	return asynqArchiveScript.Run(ctx, b.rdb,
+		[]string{
+			asynqKey(d.channel, "active"),
+			asynqKey(d.channel, "lease"),
+			asynqKey(d.channel, "archived"),
+			asynqTaskKey(d.channel, d.id),
+			asynqStatsKey(d.channel, "processed", now),
+			asynqStatsKey(d.channel, "failed", now),
+			asynqKey(d.channel, "processed"),
+			asynqKey(d.channel, "failed"),
+		},
+		d.id, t.marshal(), now.Unix(), now.Add(-asynqArchivedTTL).Unix(),
+		asynqArchivedMax, int64(asynqStatsTTL/time.Second),
+	).Err()
+}
+
+// requeue hands the task back to its queue, data is not used since the
+// task is stored apart from the queue.
+func (b *asynqBroker) requeue(ctx context.Context, d *delivery, data []byte) error {
+	t, err := b.release(ctx, d)
+	if err != nil {
+		return err
+	}
+	return asynqRequeueScript.Run(ctx, b.rdb,
+		[]string{
+			asynqKey(d.channel, "active"),
+			asynqKey(d.channel, "lease"),
+			asynqKey(d.channel, "pending"),
+			asynqTaskKey(d.channel, d.id),
+		},
+		d.id, t.marshal(), time.Now().UnixNano(),
+	).Err()
+}
+
+// retry moves the task to the asynq retry set, to run again at the given
+// time.
+func (b *asynqBroker) retry(ctx context.Context, d *delivery, at time.Time) error {
+	t, err := b.release(ctx, d)
+	if err != nil {
+		return err
+	}
+	return asynqRetryScript.Run(ctx, b.rdb,
+		[]string{
+			asynqKey(d.channel, "active"),
+			asynqKey(d.channel, "lease"),
+			asynqKey(d.channel, "retry"),
+			asynqTaskKey(d.channel, d.id),
+		},
+		d.id, t.marshal(), at.Unix(),
+	).Err()
+}
+
+// touch extends the lease of the task.
+func (b *asynqBroker) touch(ctx context.Context, d *delivery) error {
+	return b.rdb.ZAddXX(ctx, asynqKey(d.channel, "lease"), redis.Z{
+		Score:  float64(time.Now().Add(asynqLeaseDuration).Unix()),
+		Member: d.id,
+	}).Err()
+}
+
+func (b *asynqBroker) close() error {
+	close(b.stop)
+	b.wg.Wait()
+	return nil
+}
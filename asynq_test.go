@@ -0,0 +1,125 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestAsynqTask(t *testing.T) {
+	task := &asynqTask{
+		Type:      "email:send",
+		Payload:   []byte(`{"to":"a@b.c"}`),
+		ID:        "8f2b",
+		Queue:     "default",
+		Retry:     25,
+		Retried:   2,
+		Timeout:   1800,
+		UniqueKey: "asynq:{default}:unique:x",
+	}
+	got, err := parseAsynqTask(task.marshal())
+	require.NoError(t, err)
+	assert.Equal(t, task, got)
+
+	_, err = parseAsynqTask([]byte{0x0a, 0x05, 'a'})
+	assert.Error(t, err)
+}
+
+func TestAsynq(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var types []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("default"),
+		WithDeliveryMode(Asynq),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			md, _ := MetadataFromContext(ctx)
+			types = append(types, md.Headers[AsynqTypeHeader])
+			if string(m.Payload()) == "fail" {
+				return errors.New("failed")
+			}
+			return nil
+		}),
+	)
+	rdb := w.Redis()
+
+	// jobs are queued as asynq tasks
+	qctx := ContextWithMetadata(ctx, Metadata{Headers: map[string]string{AsynqTypeHeader: "email:send"}})
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{Timeout: job.Time(time.Minute)})
+	id, err := w.QueueWithID(qctx, &m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{id}, rdb.LRange(ctx, "asynq:{default}:pending", 0, -1).Val())
+	assert.True(t, rdb.SIsMember(ctx, "asynq:queues", "default").Val())
+	fields := rdb.HGetAll(ctx, "asynq:{default}:t:"+id).Val()
+	assert.Equal(t, "pending", fields["state"])
+	task, err := parseAsynqTask([]byte(fields["msg"]))
+	require.NoError(t, err)
+	assert.Equal(t, "email:send", task.Type)
+	assert.Equal(t, "foo", string(task.Payload))
+	assert.Equal(t, int64(60), task.Timeout)
+
+	// and tasks queued by asynq are processed
+	producerTask := &asynqTask{Type: "image:resize", Payload: []byte("fail"), ID: "asynq-1", Queue: "default"}
+	rdb.HSet(ctx, "asynq:{default}:t:asynq-1", "msg", producerTask.marshal(), "state", "pending")
+	rdb.LPush(ctx, "asynq:{default}:pending", "asynq-1")
+
+	for i := 0; i < 2; i++ {
+		msg, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		_ = w.Run(ctx, msg)
+	}
+	assert.Equal(t, []string{"email:send", "image:resize"}, types)
+
+	assert.Zero(t, rdb.Exists(ctx, "asynq:{default}:t:"+id).Val())
+	assert.Equal(t, "2", rdb.Get(ctx, "asynq:{default}:processed").Val())
+	assert.Equal(t, "1", rdb.Get(ctx, "asynq:{default}:failed").Val())
+	assert.Equal(t, []string{"asynq-1"}, rdb.ZRange(ctx, "asynq:{default}:archived", 0, -1).Val())
+	assert.Equal(t, "archived", rdb.HGet(ctx, "asynq:{default}:t:asynq-1", "state").Val())
+	assert.Zero(t, rdb.LLen(ctx, "asynq:{default}:active").Val())
+	assert.Zero(t, rdb.ZCard(ctx, "asynq:{default}:lease").Val())
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestAsynqRetry(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("retries"),
+		WithDeliveryMode(Asynq),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			return Retry{After: time.Second}
+		}),
+	)
+	rdb := w.Redis()
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	id, err := w.QueueWithID(ctx, &m)
+	require.NoError(t, err)
+	msg, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, msg))
+
+	assert.Equal(t, []string{id}, rdb.ZRange(ctx, "asynq:{retries}:retry", 0, -1).Val())
+	task, err := parseAsynqTask([]byte(rdb.HGet(ctx, "asynq:{retries}:t:"+id, "msg").Val()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), task.Retried)
+
+	// the task is forwarded back to the queue once due
+	msg, err = w.Fetch(ctx, 3*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(msg.Payload()))
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,65 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeaseExpired is reported when a job releases a concurrency slot or an
+// ordering lock whose lease already expired, which means it ran longer than
+// its lease and other jobs may have run concurrently. See ExtendLease.
+var ErrLeaseExpired = errors.New("redisdb: lease expired before release")
+
+// lockExpired records leases that expired without being released, which
+// usually means their holder crashed or is stuck.
+func (w *Worker) lockExpired(key string, n int64) {
+	w.log.Warn("leases expired without being released", "lock", key, "count", n)
+	w.metrics.recordLocksExpired(context.Background(), key, n)
+}
+
+// lockKeys returns the semaphores and locks used by the worker.
+func (w *Worker) lockKeys() []string {
+	var keys []string
+	for _, channel := range w.opts.channels {
+		if w.concurrencyLimit(channel) > 0 {
+			keys = append(keys, concurrencyKey(channel))
+		}
+		if w.opts.strictOrder && w.opts.mode != PubSub {
+			keys = append(keys, orderKey(channel))
+		}
+	}
+	if w.opts.jobType != nil {
+		for jobType := range w.opts.typeConcurrency {
+			keys = append(keys, w.opts.key(typeConcurrencyKey(jobType)))
+		}
+	}
+	return keys
+}
+
+// auditLocks releases the expired leases of the semaphores and locks used
+// by the worker, so they are recovered and reported even when no worker
+// waits for them.
+func (w *Worker) auditLocks(ctx context.Context) error {
+	var errs []error
+	for _, key := range w.lockKeys() {
+		errs = append(errs, w.newSemaphore(key, 0).expire(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (w *Worker) watchLocks() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(lockAuditInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.auditLocks(context.Background()); err != nil {
+				w.log.Error("failed to audit locks", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestAuditLocks(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("audit"),
+		WithMaxConcurrency(2),
+		WithMeterProvider(mp),
+	)
+	assert.Equal(t, []string{concurrencyKey("audit")}, w.lockKeys())
+
+	// a stuck holder whose lease expires
+	sem := w.newSemaphore(concurrencyKey("audit"), 2)
+	token, err := sem.acquire(ctx, 100*time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	assert.NoError(t, w.auditLocks(ctx))
+	assert.Equal(t, int64(0), w.Redis().ZCard(ctx, concurrencyKey("audit")).Val())
+	assert.Equal(t, int64(1), collectSums(t, reader)["redisdb.locks.expired"])
+
+	// the holder learns that its lease expired
+	assert.ErrorIs(t, sem.release(ctx, token), ErrLeaseExpired)
+	assert.NoError(t, w.Shutdown())
+}
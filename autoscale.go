@@ -0,0 +1,136 @@
+package redisdb
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// throughputWindow is the history SuggestedWorkerCount reads the
+	// arrival rate and processing time from.
+	throughputWindow = 5 * time.Minute
+	// throughputBucketTTL is how long the buckets of a minute are kept.
+	throughputBucketTTL = 2 * throughputWindow
+	// backlogDrainTarget is how fast SuggestedWorkerCount drains the
+	// waiting jobs, on top of keeping up with the arrivals.
+	backlogDrainTarget = time.Minute
+)
+
+// throughputKey is the hash counting the jobs of channel queued and run
+// during the minute starting at t.
+func throughputKey(channel string, t time.Time) string {
+	return channel + ":throughput:" + strconv.FormatInt(t.Unix()/60, 10)
+}
+
+// throughput counts the jobs of a channel until they are written to Redis
+// by the next heartbeat.
+type throughput struct {
+	queued atomic.Int64
+	done   atomic.Int64
+	busyMs atomic.Int64
+}
+
+// throughputs maps the channels to their counters.
+type throughputs struct {
+	m sync.Map
+}
+
+func (t *throughputs) channel(name string) *throughput {
+	v, _ := t.m.LoadOrStore(name, &throughput{})
+	return v.(*throughput)
+}
+
+func (t *throughputs) recordQueued(channel string) {
+	t.channel(channel).queued.Add(1)
+}
+
+func (t *throughputs) recordDone(channel string, d time.Duration) {
+	c := t.channel(channel)
+	c.done.Add(1)
+	c.busyMs.Add(d.Milliseconds())
+}
+
+// flushThroughput adds the counted jobs to the bucket of the current
+// minute.
+func (w *Worker) flushThroughput(ctx context.Context) error {
+	now := time.Now()
+	_, err := w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		w.throughput.m.Range(func(k, v any) bool {
+			c := v.(*throughput)
+			queued, done, busy := c.queued.Swap(0), c.done.Swap(0), c.busyMs.Swap(0)
+			if queued == 0 && done == 0 {
+				return true
+			}
+			key := throughputKey(k.(string), now)
+			pipe.HIncrBy(ctx, key, "queued", queued)
+			pipe.HIncrBy(ctx, key, "done", done)
+			pipe.HIncrBy(ctx, key, "busy_ms", busy)
+			pipe.Expire(ctx, key, throughputBucketTTL)
+			return true
+		})
+		return nil
+	})
+	return err
+}
+
+// SuggestedWorkerCount returns how many jobs should run concurrently on
+// the consumed channels, for autoscalers: enough to keep up with the
+// arrival rate and to drain the waiting jobs within a minute, from the
+// jobs queued and run by all the workers over the last 5 minutes. It is
+// zero when the channels are idle, and 1 while there is a backlog but no
+// job finished yet to measure the processing time.
+func (w *Worker) SuggestedWorkerCount(ctx context.Context) (int, error) {
+	now := time.Now()
+	var queued, done, busyMs int64
+	for _, channel := range w.opts.channels {
+		cmds, err := w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for t := now.Add(-throughputWindow); !t.After(now); t = t.Add(time.Minute) {
+				pipe.HMGet(ctx, throughputKey(channel, t), "queued", "done", "busy_ms")
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, cmd := range cmds {
+			vals := cmd.(*redis.SliceCmd).Val()
+			queued += parseCount(vals[0])
+			done += parseCount(vals[1])
+			busyMs += parseCount(vals[2])
+		}
+	}
+
+	counts, err := (&Inspector{w: w}).Counts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var backlog int64
+	for _, c := range counts {
+		backlog += c.Jobs[JobPending] + c.Jobs[JobActive]
+	}
+
+	if done == 0 {
+		if backlog > 0 || queued > 0 {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	// the window spans the whole minutes before the current one
+	window := throughputWindow + now.Sub(now.Truncate(time.Minute))
+	avg := float64(busyMs) / float64(done) / 1000
+	rate := float64(queued) / window.Seconds()
+	n := rate*avg + float64(backlog)*avg/backlogDrainTarget.Seconds()
+	return int(math.Ceil(n)), nil
+}
+
+func parseCount(v any) int64 {
+	s, _ := v.(string)
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
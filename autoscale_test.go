@@ -0,0 +1,51 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestSuggestedWorkerCount(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "autoscale"
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+	)
+	defer w.Shutdown()
+
+	n, err := w.SuggestedWorkerCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	for range 30 {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		require.NoError(t, w.Queue(&m))
+	}
+	require.NoError(t, w.flushThroughput(ctx))
+	n, err = w.SuggestedWorkerCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// 90 jobs queued and 60 run for 10 seconds each within about 5
+	// minutes: 3 workers keep up with the arrivals and 5 more drain the
+	// 30 waiting jobs within a minute
+	for range 60 {
+		w.throughput.recordQueued(channel)
+		w.throughput.recordDone(channel, 10*time.Second)
+	}
+	require.NoError(t, w.flushThroughput(ctx))
+	n, err = w.SuggestedWorkerCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 8, n)
+}
@@ -0,0 +1,57 @@
+package redisdb
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// WithAWSCluster connects to an AWS ElastiCache cluster with cluster mode
+// enabled, or to a MemoryDB cluster, through its configuration endpoint
+// (host:port), from which the nodes are discovered. TLS is required, as
+// in-transit encryption is on for MemoryDB and for the ElastiCache
+// clusters using an auth token. Leave username empty to authenticate with
+// an ElastiCache auth token, or set the ACL user of MemoryDB.
+//
+// These services restrict some commands, CLIENT SETINFO is not sent and
+// scripts fall back to EVALSHA when functions cannot be loaded. They run
+// multi-key scripts only on keys of one slot, so list and stream channels
+// need a hash tag (e.g. "{jobs}") or WithShards, which NewWorker checks.
+func WithAWSCluster(endpoint, username, password string) Option {
+	return func(w *options) {
+		w.addr = endpoint
+		w.cluster = true
+		w.username = username
+		w.password = password
+		if w.tls == nil {
+			w.tls = &tls.Config{
+				MinVersion: tls.VersionTLS12,
+			}
+		}
+		w.managed = true
+	}
+}
+
+// checkManaged checks the channels of a managed cluster, where the keys of
+// a channel must share a slot, see WithAWSCluster.
+func (o *options) checkManaged() error {
+	if !o.managed || (o.mode != List && o.mode != Stream) {
+		return nil
+	}
+	for _, channel := range o.channels {
+		if !hasHashTag(channel) {
+			return fmt.Errorf("redisdb: channel %q needs a hash tag or WithShards on a managed cluster", channel)
+		}
+	}
+	return nil
+}
+
+// hasHashTag reports whether the key has a non-empty cluster hash tag.
+func hasHashTag(key string) bool {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return false
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	return end > 0
+}
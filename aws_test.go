@@ -0,0 +1,43 @@
+package redisdb
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAWSCluster(t *testing.T) {
+	opts := newOptions(
+		WithAWSCluster("clustercfg.jobs.abc123.use1.cache.amazonaws.com:6379", "", "token"),
+		WithChannel("jobs"),
+		WithDeliveryMode(List),
+	)
+	assert.True(t, opts.cluster)
+	assert.Equal(t, "token", opts.password)
+	require.NotNil(t, opts.tls)
+	assert.Equal(t, uint16(tls.VersionTLS12), opts.tls.MinVersion)
+	assert.Error(t, opts.checkManaged())
+
+	for _, extra := range [][]Option{
+		{WithChannel("{jobs}")},
+		{WithShards(4)},
+		{WithDeliveryMode(PubSub)},
+	} {
+		opts := newOptions(append([]Option{
+			WithAWSCluster("clustercfg:6379", "app", "secret"),
+			WithChannel("jobs"),
+			WithDeliveryMode(Stream),
+		}, extra...)...)
+		assert.NoError(t, opts.checkManaged())
+	}
+}
+
+func TestHasHashTag(t *testing.T) {
+	assert.True(t, hasHashTag("{jobs}"))
+	assert.True(t, hasHashTag("app:{jobs}:high"))
+	assert.False(t, hasHashTag("jobs"))
+	assert.False(t, hasHashTag("{}jobs"))
+	assert.False(t, hasHashTag("jobs}{"))
+}
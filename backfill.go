@@ -0,0 +1,125 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BackfillIterator returns the page of messages that follows cursor and the
+// cursor of the page after it. The first call receives an empty cursor and
+// an empty next cursor ends the backfill. Given the same cursor, the
+// iterator must return the same page so that a backfill can be resumed.
+type BackfillIterator func(ctx context.Context, cursor string) ([]core.QueuedMessage, string, error)
+
+// BackfillOptions configures Backfill.
+type BackfillOptions struct {
+	// Name identifies the backfill. Its progress is checkpointed in Redis
+	// under this name after every page, and running a backfill with the
+	// name of one that was interrupted resumes it from its last page.
+	Name string
+	// Channel receives the messages, the first channel of the worker
+	// by default.
+	Channel string
+	// Rate caps the number of messages enqueued per second, 0 means
+	// no limit.
+	Rate int
+	// Job sets the timeout and retries of every enqueued job.
+	Job job.AllowOption
+}
+
+func backfillKey(channel, name string) string {
+	return channel + ":backfill:" + name
+}
+
+// Backfill bulk-enqueues the messages returned by next, one pipeline per
+// page. It returns the number of messages enqueued by the backfill so far,
+// including the ones enqueued before it was resumed. The checkpoint is
+// removed once the iterator is exhausted.
+//
+// A page is enqueued before its checkpoint is saved, so the page that was
+// in flight when a backfill was interrupted may be enqueued twice.
+func (w *Worker) Backfill(ctx context.Context, next BackfillIterator, opts BackfillOptions) (int64, error) {
+	if atomic.LoadInt32(&w.stopFlag) == 1 {
+		return 0, queue.ErrQueueShutdown
+	}
+	if opts.Name == "" {
+		return 0, errors.New("redisdb: backfill name is required")
+	}
+	channel := opts.Channel
+	if channel == "" {
+		channel = w.opts.channels[0]
+	}
+	key := backfillKey(channel, opts.Name)
+
+	checkpoint, err := w.rdb.HMGet(ctx, key, "cursor", "enqueued").Result()
+	if err != nil {
+		return 0, err
+	}
+	cursor, _ := checkpoint[0].(string)
+	var total int64
+	if s, ok := checkpoint[1].(string); ok {
+		total, _ = strconv.ParseInt(s, 10, 64)
+	}
+
+	start := time.Now()
+	var sent int64
+	for {
+		msgs, nextCursor, err := next(ctx, cursor)
+		if err != nil {
+			return total, err
+		}
+
+		_, err = w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, m := range msgs {
+				msg := job.NewMessage(m, opts.Job)
+				data, err := w.encode(&msg, envelope{})
+				if err != nil {
+					return err
+				}
+				if err := w.broker.push(ctx, pipe, channel, data); err != nil {
+					return err
+				}
+			}
+			if nextCursor == "" {
+				pipe.Del(ctx, key)
+			} else {
+				pipe.HSet(ctx, key, "cursor", nextCursor, "enqueued", total+int64(len(msgs)))
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		for range msgs {
+			w.metrics.recordPublished(ctx, channel)
+		}
+		total += int64(len(msgs))
+		sent += int64(len(msgs))
+
+		if nextCursor == "" {
+			return total, nil
+		}
+		cursor = nextCursor
+
+		if opts.Rate > 0 {
+			// pace the pages so the average rate stays under the limit
+			wait := time.Until(start.Add(time.Duration(sent) * time.Second / time.Duration(opts.Rate)))
+			if wait > 0 {
+				select {
+				case <-ctx.Done():
+					return total, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/golang-queue/queue/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestBackfillResume(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("backfill"),
+		WithDeliveryMode(List),
+	)
+
+	failAt := "20"
+	pages := func(ctx context.Context, cursor string) ([]core.QueuedMessage, string, error) {
+		if cursor == failAt {
+			return nil, "", errors.New("interrupted")
+		}
+		from, _ := strconv.Atoi(cursor)
+		var msgs []core.QueuedMessage
+		for i := from; i < from+10 && i < 25; i++ {
+			msgs = append(msgs, mockMessage{Message: strconv.Itoa(i)})
+		}
+		if from+10 >= 25 {
+			return msgs, "", nil
+		}
+		return msgs, strconv.Itoa(from + 10), nil
+	}
+	opts := BackfillOptions{Name: "users", Rate: 1000}
+
+	n, err := w.Backfill(ctx, pages, opts)
+	assert.EqualError(t, err, "interrupted")
+	assert.Equal(t, int64(20), n)
+
+	failAt = ""
+	n, err = w.Backfill(ctx, pages, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(25), n)
+
+	assert.Equal(t, int64(25), w.Redis().LLen(ctx, "backfill").Val())
+	assert.Equal(t, int64(0), w.Redis().Exists(ctx, backfillKey("backfill", "users")).Val())
+
+	_, err = w.Backfill(ctx, pages, BackfillOptions{})
+	assert.Error(t, err)
+	assert.NoError(t, w.Shutdown())
+}
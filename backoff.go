@@ -0,0 +1,192 @@
+package redisdb
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+)
+
+// Backoff computes how long to wait before an attempt. attempt starts at 1
+// for the first retry.
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+// BackoffFunc adapts a function to the Backoff interface.
+type BackoffFunc func(attempt int) time.Duration
+
+// Delay returns f(attempt).
+func (f BackoffFunc) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ConstantBackoff waits d before every attempt.
+func ConstantBackoff(d time.Duration) Backoff {
+	return BackoffFunc(func(int) time.Duration {
+		return d
+	})
+}
+
+// LinearBackoff waits step more before every attempt, up to max.
+func LinearBackoff(step, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		return capDelay(float64(step)*float64(attempt), max)
+	})
+}
+
+// ExponentialBackoff waits min before the first attempt and factor times
+// longer before every next one, up to max.
+func ExponentialBackoff(min, max time.Duration, factor float64) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		return capDelay(float64(min)*math.Pow(factor, float64(attempt-1)), max)
+	})
+}
+
+// FibonacciBackoff waits unit times the Fibonacci number of the attempt
+// (1, 1, 2, 3, 5, ...), up to max.
+func FibonacciBackoff(unit, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		a, b := 0.0, 1.0
+		for i := 0; i < attempt; i++ {
+			a, b = b, a+b
+			if a*float64(unit) >= float64(max) {
+				break
+			}
+		}
+		return capDelay(a*float64(unit), max)
+	})
+}
+
+// Jitter randomizes the delays of a backoff, so that the jobs failing
+// together, for instance during an outage, are not all retried at once.
+type Jitter int
+
+const (
+	// NoJitter keeps the delays of the backoff.
+	NoJitter Jitter = iota
+	// FullJitter waits a random delay between zero and the delay of the
+	// backoff.
+	FullJitter
+	// EqualJitter waits half the delay of the backoff plus a random delay
+	// up to the other half.
+	EqualJitter
+	// DecorrelatedJitter waits a random delay between the first delay of
+	// the backoff and three times the previous delay, up to a maximum.
+	DecorrelatedJitter
+)
+
+func (j Jitter) String() string {
+	switch j {
+	case NoJitter:
+		return "none"
+	case FullJitter:
+		return "full"
+	case EqualJitter:
+		return "equal"
+	case DecorrelatedJitter:
+		return "decorrelated"
+	default:
+		return ""
+	}
+}
+
+// Jittered returns b with the delays randomized by j. The delays of
+// DecorrelatedJitter grow from the previous one rather than following b,
+// up to max. The backoff returned for it depends on the previous delay,
+// use one for each sequence of attempts.
+func Jittered(b Backoff, j Jitter, max time.Duration) Backoff {
+	switch j {
+	case FullJitter, EqualJitter:
+		return BackoffFunc(func(attempt int) time.Duration {
+			return j.apply(b.Delay(attempt))
+		})
+	case DecorrelatedJitter:
+		return &decorrelated{backoff: b, max: max}
+	default:
+		return b
+	}
+}
+
+// apply randomizes the delay d alone. DecorrelatedJitter, which depends on
+// the previous delay, is applied as FullJitter.
+func (j Jitter) apply(d time.Duration) time.Duration {
+	switch j {
+	case FullJitter, DecorrelatedJitter:
+		return randDelay(0, d)
+	case EqualJitter:
+		return d/2 + randDelay(0, d-d/2)
+	default:
+		return d
+	}
+}
+
+// decorrelated implements DecorrelatedJitter.
+type decorrelated struct {
+	backoff Backoff
+	max     time.Duration
+	mu      sync.Mutex
+	prev    time.Duration
+}
+
+func (d *decorrelated) Delay(attempt int) time.Duration {
+	base := min(d.backoff.Delay(1), d.max)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if attempt <= 1 || d.prev < base {
+		d.prev = base
+	}
+	d.prev = randDelay(base, capDelay(3*float64(d.prev), d.max))
+	return d.prev
+}
+
+// randDelay returns a random delay in [lo, hi].
+func randDelay(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	return lo + time.Duration(rand.Int64N(int64(hi-lo)+1))
+}
+
+// claimBackoff paces the attempts to take a slot or a lock held by other
+// workers.
+func (w *Worker) claimBackoff() Backoff {
+	if w.opts.backoff != nil {
+		return w.opts.backoff
+	}
+	return ConstantBackoff(semaphorePollInterval)
+}
+
+// retryBackoff paces the retries of m run by ProcessN. A fixed retry delay
+// set on the job takes precedence. Decorrelated delays are bounded by the
+// RetryMax of the job.
+func (w *Worker) retryBackoff(m *job.Message) Backoff {
+	switch {
+	case m.RetryDelay > 0:
+		return ConstantBackoff(m.RetryDelay)
+	case w.opts.backoff != nil:
+		return Jittered(w.opts.backoff, w.opts.jitter, m.RetryMax)
+	default:
+		return Jittered(ExponentialBackoff(m.RetryMin, m.RetryMax, m.RetryFactor), w.opts.jitter, m.RetryMax)
+	}
+}
+
+// reconnectBackoff paces Fetch while Redis cannot be reached.
+func (w *Worker) reconnectBackoff() Backoff {
+	if w.opts.backoff != nil {
+		return w.opts.backoff
+	}
+	return ExponentialBackoff(100*time.Millisecond, 5*time.Second, 2)
+}
+
+// capDelay converts d to a duration no longer than max, guarding against
+// overflows.
+func capDelay(d float64, max time.Duration) time.Duration {
+	if d > float64(max) || math.IsInf(d, 0) || math.IsNaN(d) {
+		return max
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,86 @@
+package redisdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration
+	}{
+		{
+			name:    "constant",
+			backoff: ConstantBackoff(time.Second),
+			want:    []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:    "linear",
+			backoff: LinearBackoff(time.Second, 5*time.Second),
+			want:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:    "exponential",
+			backoff: ExponentialBackoff(100*time.Millisecond, time.Second, 2),
+			want:    []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second},
+		},
+		{
+			name:    "fibonacci",
+			backoff: FibonacciBackoff(time.Second, 6*time.Second),
+			want:    []time.Duration{time.Second, time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 6 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				assert.Equal(t, want, tt.backoff.Delay(i+1), "attempt %d", i+1)
+			}
+		})
+	}
+
+	// large attempts do not overflow
+	assert.Equal(t, time.Minute, ExponentialBackoff(time.Second, time.Minute, 2).Delay(1000))
+	assert.Equal(t, time.Minute, FibonacciBackoff(time.Second, time.Minute).Delay(1000))
+}
+
+func TestJittered(t *testing.T) {
+	b := ExponentialBackoff(100*time.Millisecond, 10*time.Second, 2)
+
+	assert.Equal(t, b.Delay(3), Jittered(b, NoJitter, 10*time.Second).Delay(3))
+	for i := 0; i < 100; i++ {
+		d := Jittered(b, FullJitter, 10*time.Second).Delay(3)
+		assert.True(t, d >= 0 && d <= 400*time.Millisecond, d)
+
+		d = Jittered(b, EqualJitter, 10*time.Second).Delay(3)
+		assert.True(t, d >= 200*time.Millisecond && d <= 400*time.Millisecond, d)
+
+		// the delays of WithRetryDelayFunc
+		d = EqualJitter.apply(time.Hour)
+		assert.True(t, d >= 30*time.Minute && d <= time.Hour, d)
+		d = DecorrelatedJitter.apply(time.Hour)
+		assert.True(t, d >= 0 && d <= time.Hour, d)
+	}
+	assert.Equal(t, time.Hour, NoJitter.apply(time.Hour))
+
+	decorrelated := Jittered(b, DecorrelatedJitter, 5*time.Second)
+	prev := 100 * time.Millisecond
+	for attempt := 1; attempt <= 20; attempt++ {
+		d := decorrelated.Delay(attempt)
+		assert.True(t, d >= 100*time.Millisecond && d <= min(3*prev, 5*time.Second), "attempt %d: %s", attempt, d)
+		prev = d
+	}
+
+	// backoffs that never stop growing are bounded by max
+	unbounded := BackoffFunc(func(attempt int) time.Duration {
+		return time.Duration(attempt) * time.Second
+	})
+	decorrelated = Jittered(unbounded, DecorrelatedJitter, time.Minute)
+	for attempt := 1; attempt <= 20; attempt++ {
+		assert.LessOrEqual(t, decorrelated.Delay(attempt), time.Minute)
+	}
+}
@@ -0,0 +1,85 @@
+package redisdb
+
+import (
+	"errors"
+	"strings"
+)
+
+// broadcastMaxLen caps the streams of broadcast channels, which are never
+// emptied as every worker reads all the entries.
+const broadcastMaxLen = 10000
+
+// WithBroadcast delivers every message of the channels to every running
+// worker instead of one of them, for messages such as configuration
+// refreshes. Each worker reads the streams in its own consumer group, named
+// after the group and the consumer name, so workers need distinct and
+// stable consumer names, set with WithConsumerName. A worker receives the
+// messages added after its group was created, and those added while it
+// restarts. Messages it retries or delivers again are added to a
+// <channel>:retry:<consumer> stream that it alone reads. The streams keep
+// about the last 10000 messages, and producers must be set up with
+// WithBroadcast too. It needs the Stream mode. In PubSub mode every subscribed worker already receives each
+// message, but messages published while a worker is disconnected are lost.
+func WithBroadcast() Option {
+	return func(w *options) {
+		w.broadcast = true
+	}
+}
+
+// broadcastRetryKey is the stream the consumer delivers the messages of
+// the broadcast channel again to, so that they do not run again on every
+// worker. The retry stream of a retry stream is itself.
+func broadcastRetryKey(channel, consumer string) string {
+	if strings.HasSuffix(channel, ":retry:"+consumer) {
+		return channel
+	}
+	return channel + ":retry:" + consumer
+}
+
+// broadcastChannel returns the broadcast channel of a retry stream of the
+// consumer, see broadcastRetryKey, and other channels unchanged.
+func broadcastChannel(channel, consumer string) string {
+	name, _ := strings.CutSuffix(channel, ":retry:"+consumer)
+	return name
+}
+
+// withBroadcast gives the worker its own consumer group on broadcast
+// channels, and its own retry streams, consumed after the channels.
+func (o *options) withBroadcast() {
+	if !o.broadcast || o.mode != Stream {
+		return
+	}
+	o.consumerGroup += ":" + o.consumerName
+	channels := make([]string, 0, len(o.channels)*2)
+	channels = append(channels, o.channels...)
+	for _, channel := range o.channels {
+		channels = append(channels, broadcastRetryKey(channel, o.consumerName))
+	}
+	o.channels = channels
+}
+
+// retryChannel returns the channel the messages of channel are delivered
+// again to.
+func (o *options) retryChannel(channel string) string {
+	if !o.broadcast || o.mode != Stream {
+		return channel
+	}
+	return broadcastRetryKey(channel, o.consumerName)
+}
+
+// checkBroadcast checks that the delivery mode supports broadcasting, see
+// WithBroadcast.
+func (o *options) checkBroadcast() error {
+	if !o.broadcast {
+		return nil
+	}
+	if o.mode != Stream {
+		return errors.New("redisdb: broadcast needs the Stream delivery mode")
+	}
+	// the default name changes with every restart, each leaving a group
+	// behind that keeps the entries of the streams
+	if o.consumerName == defaultConsumerName() {
+		return errors.New("redisdb: broadcast needs a consumer name, see WithConsumerName")
+	}
+	return nil
+}
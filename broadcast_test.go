@@ -0,0 +1,109 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestBroadcast(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	newWorker := func(name string) *Worker {
+		w := NewWorker(
+			WithAddr(endpoint),
+			WithChannel("config"),
+			WithDeliveryMode(Stream),
+			WithConsumerName(name),
+			WithBroadcast(),
+		)
+		t.Cleanup(func() { _ = w.Shutdown() })
+		return w
+	}
+	w1, w2 := newWorker("worker-1"), newWorker("worker-2")
+	assert.Equal(t, defaultConsumerGroup+":worker-1", w1.opts.consumerGroup)
+
+	m := job.NewMessage(mockMessage{Message: "refresh"})
+	require.NoError(t, w1.Queue(&m))
+
+	for _, w := range []*Worker{w1, w2} {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "refresh", string(task.Payload()))
+		require.NoError(t, w.Run(ctx, task))
+	}
+
+	// workers started later only receive the next messages
+	w3 := newWorker("worker-3")
+	_, err := w3.Fetch(ctx, 100*time.Millisecond)
+	assert.ErrorIs(t, err, queue.ErrNoTaskInQueue)
+}
+
+func TestBroadcastRetry(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	runs := map[string]int{}
+	newWorker := func(name string, run func(context.Context, core.TaskMessage) error) *Worker {
+		w := NewWorker(
+			WithAddr(endpoint),
+			WithChannel("config-retry"),
+			WithDeliveryMode(Stream),
+			WithConsumerName(name),
+			WithBroadcast(),
+			WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+				runs[name]++
+				return run(ctx, m)
+			}),
+		)
+		t.Cleanup(func() { _ = w.Shutdown() })
+		return w
+	}
+	w1 := newWorker("worker-1", func(ctx context.Context, m core.TaskMessage) error {
+		if runs["worker-1"] == 1 {
+			return Retry{After: 10 * time.Millisecond}
+		}
+		return nil
+	})
+	w2 := newWorker("worker-2", func(ctx context.Context, m core.TaskMessage) error {
+		return nil
+	})
+
+	m := job.NewMessage(mockMessage{Message: "refresh"})
+	require.NoError(t, w1.Queue(&m))
+	for _, w := range []*Worker{w1, w2} {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, w.Run(ctx, task))
+	}
+
+	// the retry runs on the worker that failed only
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, w1.moveDelayed(ctx))
+	assert.Equal(t, int64(1), w1.Redis().XLen(ctx, "config-retry").Val())
+	assert.Equal(t, int64(1), w1.Redis().XLen(ctx, "config-retry:retry:worker-1").Val())
+	_, err := w2.Fetch(ctx, 100*time.Millisecond)
+	assert.ErrorIs(t, err, queue.ErrNoTaskInQueue)
+	task, err := w1.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w1.Run(ctx, task))
+	assert.Equal(t, map[string]int{"worker-1": 2, "worker-2": 1}, runs)
+}
+
+func TestBroadcastConsumerName(t *testing.T) {
+	o := newOptions(WithDeliveryMode(Stream), WithBroadcast())
+	assert.Error(t, o.checkBroadcast())
+	o = newOptions(WithDeliveryMode(Stream), WithBroadcast(), WithConsumerName("worker-1"))
+	assert.NoError(t, o.checkBroadcast())
+	assert.Equal(t, []string{"queue", "queue:retry:worker-1"}, o.channels)
+}
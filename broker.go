@@ -0,0 +1,104 @@
+package redisdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DeliveryMode selects how messages are carried through Redis.
+type DeliveryMode int
+
+const (
+	// PubSub publishes messages with PUBLISH. Delivery is fire-and-forget:
+	// messages published while no worker is subscribed are lost.
+	PubSub DeliveryMode = iota
+	// List pushes messages onto a Redis list with LPUSH. Workers move them to
+	// their own processing list with a Lua script that also records the claim,
+	// and remove them once handled; messages that fail all their attempts are
+	// moved to the <channel>:dead stream.
+	// In a Redis Cluster use a hash tag in the channel name, e.g. "{jobs}",
+	// so that all the keys of the channel live in the same slot.
+	List
+	// Stream appends messages to a Redis stream with XADD and reads them
+	// through a consumer group. Messages are acknowledged once handled,
+	// messages that fail all their attempts are moved to the <channel>:dead stream.
+	Stream
+	// Asynq reads and writes the queues of hibiken/asynq, so that asynq
+	// servers and workers can drain the same queues while migrating. Channels
+	// are asynq queue names and payloads are stored as they are. Messages
+	// that fail all their attempts are archived the way asynq does.
+	Asynq
+	// Sidekiq pushes jobs in the Sidekiq format onto the queue:<channel>
+	// lists, so Ruby Sidekiq processes can run them, and consumes them
+	// through processing lists like List. Job args are the payload, the
+	// class is set with the SidekiqClassHeader header. Messages that fail
+	// all their attempts are moved to the Sidekiq dead set.
+	Sidekiq
+	// Celery pushes jobs as Celery protocol version 2 messages onto the list
+	// of their queue, so Python Celery workers run them. The task name is set
+	// with the CeleryTaskHeader header. Workers in this mode only produce,
+	// Fetch always reports an empty queue.
+	Celery
+)
+
+// String returns the name of the delivery mode.
+func (m DeliveryMode) String() string {
+	switch m {
+	case PubSub:
+		return "pubsub"
+	case List:
+		return "list"
+	case Stream:
+		return "stream"
+	case Asynq:
+		return "asynq"
+	case Sidekiq:
+		return "sidekiq"
+	case Celery:
+		return "celery"
+	default:
+		return "unknown"
+	}
+}
+
+// delivery is a message received from Redis together with the data
+// needed to acknowledge it.
+type delivery struct {
+	channel string
+	// id is the stream entry ID in stream mode.
+	id   string
+	data []byte
+	// lock is held until the delivery is settled in strict order mode.
+	lock *orderLock
+	// env is the envelope of the message.
+	env envelope
+	// attempts counts the runs of the job of the delivery.
+	attempts int32
+	// retryCount, when set, replaces the retries left of the message
+	// queued again, see WithRetryDelayFunc.
+	retryCount *int64
+}
+
+// broker moves messages between the worker and Redis for one delivery mode.
+type broker interface {
+	// push stores a message for delivery on the given channel through
+	// rdb, which may be a pipeline.
+	push(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error
+	// pop waits up to timeout for the next message on any of the
+	// consumed channels and returns
+	// queue.ErrNoTaskInQueue when none arrives.
+	pop(ctx context.Context, timeout time.Duration) (*delivery, error)
+	// ack marks a delivery as handled.
+	ack(ctx context.Context, d *delivery) error
+	// reject marks a delivery that failed and will not be retried.
+	reject(ctx context.Context, d *delivery) error
+	// requeue hands a delivery back to the channel as data, the message
+	// of the delivery when it was not processed.
+	requeue(ctx context.Context, d *delivery, data []byte) error
+	// touch records that a delivery is still being processed.
+	touch(ctx context.Context, d *delivery) error
+	// close releases the resources held by the broker.
+	close() error
+}
@@ -0,0 +1,60 @@
+package redisdb
+
+import (
+	"slices"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+)
+
+// BulkLane routes large messages to a channel of their own, so that huge
+// jobs do not delay the small ones queued on the same channel.
+type BulkLane struct {
+	// Channel receives the messages whose payload is larger than
+	// Threshold bytes.
+	Channel   string
+	Threshold int
+	// MaxConcurrency limits how many bulk jobs run at the same time
+	// across all workers, 0 means no limit.
+	MaxConcurrency int
+	// Timeout replaces the timeout of bulk jobs when set.
+	Timeout time.Duration
+}
+
+// route returns the channel a task is queued to and the job to store.
+func (w *Worker) route(task core.TaskMessage, env envelope) (string, *job.Message, error) {
+	m, err := toMessage(task)
+	if err != nil {
+		return "", nil, err
+	}
+	lane := w.opts.bulkLane
+	if lane.Channel == "" || len(m.Body) <= lane.Threshold {
+		return w.shard(env), m, nil
+	}
+
+	if lane.Timeout > 0 {
+		bulk := *m
+		bulk.Timeout = lane.Timeout
+		return lane.Channel, &bulk, nil
+	}
+	return lane.Channel, m, nil
+}
+
+// concurrencyLimit returns how many jobs of channel may run at once across
+// all workers, 0 means no limit.
+func (w *Worker) concurrencyLimit(channel string) int {
+	lane := w.opts.bulkLane
+	if lane.Channel != "" && channel == lane.Channel && lane.MaxConcurrency > 0 {
+		return lane.MaxConcurrency
+	}
+	return w.opts.maxConcurrency
+}
+
+// withBulkLane adds the bulk channel to the consumed channels, after the
+// configured ones.
+func (o *options) withBulkLane() {
+	if o.bulkLane.Channel != "" && !slices.Contains(o.channels, o.bulkLane.Channel) {
+		o.channels = append(slices.Clip(o.channels), o.bulkLane.Channel)
+	}
+}
@@ -0,0 +1,51 @@
+package redisdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestBulkLane(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("interactive"),
+		WithDeliveryMode(List),
+		WithBulkLane(BulkLane{
+			Channel:   "interactive:bulk",
+			Threshold: 16,
+			Timeout:   time.Hour,
+		}),
+	)
+	assert.Equal(t, []string{"interactive", "interactive:bulk"}, w.Topology().Channels)
+
+	small := job.NewMessage(mockMessage{Message: "small"})
+	assert.NoError(t, w.Queue(&small))
+	large := job.NewMessage(mockMessage{Message: strings.Repeat("x", 32)})
+	assert.NoError(t, w.Queue(&large))
+
+	assert.Equal(t, int64(1), w.Redis().LLen(ctx, "interactive").Val())
+	assert.Equal(t, int64(1), w.Redis().LLen(ctx, "interactive:bulk").Val())
+
+	// small jobs are taken first
+	task, err := w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "small", string(task.Payload()))
+	assert.NoError(t, w.Run(ctx, task))
+
+	task, err = w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, task.(*job.Message).Timeout)
+	assert.NoError(t, w.Run(ctx, task))
+	assert.NoError(t, w.Shutdown())
+}
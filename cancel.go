@@ -0,0 +1,95 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/redis/go-redis/v9"
+)
+
+const idempotencyCancelled = "cancelled"
+
+var (
+	// ErrNoJobID is returned by Cancel when WithIdempotencyKey is not set.
+	ErrNoJobID = errors.New("redisdb: jobs have no ID")
+	// ErrJobStarted is returned by Cancel when the job already ran or is
+	// running.
+	ErrJobStarted = errors.New("redisdb: job already started")
+)
+
+// Cancel cancels a job that has not started yet on the consumed channels.
+// The job is removed from the delayed messages and marked as cancelled, so
+// that workers skip and acknowledge it when they receive it. Jobs are
+// identified by the key set with WithIdempotencyKey, which must be set.
+func (w *Worker) Cancel(ctx context.Context, jobID string) error {
+	if !w.opts.idempotency.enabled {
+		return ErrNoJobID
+	}
+
+	for _, channel := range w.opts.channels {
+		ok, err := w.rdb.SetNX(ctx, processedKey(channel, jobID), idempotencyCancelled, w.opts.idempotency.ttl).Result()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			state, err := w.rdb.Get(ctx, processedKey(channel, jobID)).Result()
+			if err != nil {
+				return err
+			}
+			if state != idempotencyCancelled {
+				return ErrJobStarted
+			}
+		}
+
+		if err := w.cancelDelayed(ctx, channel, jobID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jobCancelled reports whether the job of task, skipped by claimJob, was
+// cancelled rather than already processed.
+func (w *Worker) jobCancelled(ctx context.Context, channel string, task core.TaskMessage) bool {
+	state, err := w.rdb.Get(ctx, processedKey(channel, w.jobKey(task))).Result()
+	return err == nil && state == idempotencyCancelled
+}
+
+// cancelDelayed removes the delayed message of a job.
+func (w *Worker) cancelDelayed(ctx context.Context, channel, jobID string) error {
+	member, err := w.delayedMember(ctx, channel, jobID)
+	if err != nil || member == "" {
+		return err
+	}
+	var n *redis.IntCmd
+	_, err = w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		n = pipe.ZRem(ctx, delayedKey(channel), member)
+		pipe.HDel(ctx, delayedIDsKey(channel), hex.EncodeToString([]byte(jobID)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, data, _ := strings.Cut(member, delayedSeparator)
+	if _, env, err := w.decode([]byte(data)); n.Val() > 0 && err == nil {
+		// the job will not run, nor be skipped by a worker
+		w.finishGroup(ctx, env.Group, 1, 1)
+	}
+	return nil
+}
+
+// delayedJobID returns the ID of a serialized job, see jobKey.
+func (w *Worker) delayedJobID(data []byte) string {
+	m, env, err := w.decode(data)
+	if err != nil {
+		return ""
+	}
+	if w.opts.idempotency.key == nil {
+		return env.ID
+	}
+	return w.opts.idempotency.key(m)
+}
@@ -0,0 +1,52 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestCancel(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var runs []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("cancel"),
+		WithDeliveryMode(List),
+		WithIdempotencyKey(func(m core.TaskMessage) string {
+			return string(m.Payload())
+		}, time.Hour),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			runs = append(runs, string(m.Payload()))
+			return nil
+		}),
+	)
+
+	for _, v := range []string{"notify-1", "notify-2"} {
+		m := job.NewMessage(mockMessage{Message: v})
+		assert.NoError(t, w.Queue(&m))
+	}
+	scheduled := job.NewMessage(mockMessage{Message: "notify-3"})
+	assert.NoError(t, w.schedule(ctx, "cancel", scheduled.Bytes(), time.Now().Add(time.Hour)))
+
+	assert.NoError(t, w.Cancel(ctx, "notify-2"))
+	assert.NoError(t, w.Cancel(ctx, "notify-3"))
+	assert.Equal(t, int64(0), w.Redis().ZCard(ctx, delayedKey("cancel")).Val())
+
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"notify-1"}, runs)
+
+	assert.Equal(t, ErrJobStarted, w.Cancel(ctx, "notify-1"))
+	assert.NoError(t, w.Shutdown())
+}
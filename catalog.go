@@ -0,0 +1,69 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// catalogKey is the hash describing every declared channel, shared by all
+// the workers of a namespace, see WithNamespace.
+const catalogKey = "redisdb:catalog"
+
+// ChannelInfo describes a channel in the catalog.
+type ChannelInfo struct {
+	Name string `json:"name"`
+	// Owner is the team or service responsible for the channel.
+	Owner       string `json:"owner,omitempty"`
+	Description string `json:"description,omitempty"`
+	Mode        string `json:"mode"`
+	Codec       string `json:"codec"`
+	// Retention is how long messages are expected to be kept.
+	Retention time.Duration `json:"retention,omitempty"`
+	// DeadLetter describes what happens to the messages that fail.
+	DeadLetter string    `json:"dead_letter,omitempty"`
+	DeclaredAt time.Time `json:"declared_at"`
+}
+
+// DeclareChannel adds or replaces a channel in the catalog. The mode and
+// codec default to the ones of the worker.
+func (w *Worker) DeclareChannel(ctx context.Context, info ChannelInfo) error {
+	if info.Mode == "" {
+		info.Mode = w.topology.Mode
+	}
+	if info.Codec == "" {
+		info.Codec = w.topology.Codec
+	}
+	if info.DeclaredAt.IsZero() {
+		info.DeclaredAt = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return w.rdb.HSet(ctx, w.opts.key(catalogKey), info.Name, b).Err()
+}
+
+// ListChannels returns the declared channels sorted by name.
+func (w *Worker) ListChannels(ctx context.Context) ([]ChannelInfo, error) {
+	values, err := w.rdb.HGetAll(ctx, w.opts.key(catalogKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]ChannelInfo, 0, len(values))
+	for _, v := range values {
+		var info ChannelInfo
+		if err := json.Unmarshal([]byte(v), &info); err != nil {
+			return nil, err
+		}
+		channels = append(channels, info)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+
+	return channels, nil
+}
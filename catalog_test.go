@@ -0,0 +1,47 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestCatalog(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("orders"),
+		WithDeliveryMode(Stream),
+	)
+
+	assert.NoError(t, w.DeclareChannel(ctx, ChannelInfo{
+		Name:       "orders",
+		Owner:      "checkout",
+		Retention:  24 * time.Hour,
+		DeadLetter: "orders:dead, reviewed daily",
+	}))
+	assert.NoError(t, w.DeclareChannel(ctx, ChannelInfo{
+		Name:  "emails",
+		Owner: "notifications",
+		Mode:  "list",
+	}))
+
+	channels, err := w.ListChannels(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, channels, 2)
+	assert.Equal(t, "emails", channels[0].Name)
+	assert.Equal(t, "list", channels[0].Mode)
+	assert.Equal(t, "orders", channels[1].Name)
+	assert.Equal(t, "checkout", channels[1].Owner)
+	assert.Equal(t, "stream", channels[1].Mode)
+	assert.Equal(t, "json", channels[1].Codec)
+	assert.Equal(t, 24*time.Hour, channels[1].Retention)
+	assert.False(t, channels[1].DeclaredAt.IsZero())
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,157 @@
+package redisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ broker = (*celeryBroker)(nil)
+
+// CeleryTaskHeader is the metadata header holding the name of Celery
+// tasks, see ContextWithMetadata. It is required to queue jobs in Celery
+// mode.
+const CeleryTaskHeader = "celery-task"
+
+// ErrNoCeleryTask is returned when a job is queued in Celery mode without
+// the CeleryTaskHeader header.
+var ErrNoCeleryTask = errors.New("redisdb: celery jobs need a " + CeleryTaskHeader + " header")
+
+// celeryBody returns the body of the Celery task of a payload, the
+// [args, kwargs, embed] triple of the version 2 protocol. A JSON array is
+// the args of the task, a JSON object its kwargs, and any other payload
+// its single argument.
+func celeryBody(payload []byte) ([]byte, error) {
+	args, kwargs := sidekiqArgs(payload), json.RawMessage("{}")
+	if json.Valid(payload) && bytes.HasPrefix(bytes.TrimSpace(payload), []byte("{")) {
+		args, kwargs = json.RawMessage("[]"), payload
+	}
+	return json.Marshal([]any{args, kwargs, map[string]any{
+		"callbacks": nil,
+		"errbacks":  nil,
+		"chain":     nil,
+		"chord":     nil,
+	}})
+}
+
+// celeryBroker pushes jobs as Celery messages onto the list of their
+// queue, the way the Redis transport of kombu stores them. It only
+// produces: Celery workers consume the messages.
+type celeryBroker struct {
+	w      *Worker
+	origin string
+	stop   <-chan struct{}
+}
+
+func newCeleryBroker(w *Worker) *celeryBroker {
+	return &celeryBroker{
+		w:      w,
+		origin: "redisdb@" + defaultConsumerName(),
+		stop:   w.stop,
+	}
+}
+
+// push queues the job of data as a Celery task of the name set in its
+// headers.
+func (b *celeryBroker) push(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error {
+	m, env, err := b.w.decode(data)
+	if err != nil {
+		return err
+	}
+	task := env.Headers[CeleryTaskHeader]
+	if task == "" {
+		return ErrNoCeleryTask
+	}
+	id := env.ID
+	if id == "" {
+		id = ulid.Make().String()
+	}
+	body, err := celeryBody(m.Body)
+	if err != nil {
+		return err
+	}
+
+	var timeLimit any
+	if m.Timeout > 0 {
+		timeLimit = int64((m.Timeout + time.Second - 1) / time.Second)
+	}
+	msg, err := json.Marshal(map[string]any{
+		"body":             base64.StdEncoding.EncodeToString(body),
+		"content-encoding": "utf-8",
+		"content-type":     "application/json",
+		"headers": map[string]any{
+			"lang":        "py",
+			"task":        task,
+			"id":          id,
+			"shadow":      nil,
+			"eta":         nil,
+			"expires":     nil,
+			"group":       nil,
+			"group_index": nil,
+			"retries":     0,
+			"timelimit":   []any{nil, timeLimit},
+			"root_id":     id,
+			"parent_id":   nil,
+			"argsrepr":    string(m.Body),
+			"kwargsrepr":  "{}",
+			"origin":      b.origin,
+		},
+		"properties": map[string]any{
+			"correlation_id": id,
+			"reply_to":       "",
+			"delivery_mode":  2,
+			"delivery_info": map[string]any{
+				"exchange":    "",
+				"routing_key": channel,
+			},
+			"priority":      0,
+			"body_encoding": "base64",
+			"delivery_tag":  ulid.Make().String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return rdb.LPush(ctx, channel, msg).Err()
+}
+
+// pop waits for the timeout and reports an empty queue, Celery messages
+// are consumed by Celery workers.
+func (b *celeryBroker) pop(ctx context.Context, timeout time.Duration) (*delivery, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-b.stop:
+		return nil, queue.ErrQueueHasBeenClosed
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	return nil, queue.ErrNoTaskInQueue
+}
+
+func (b *celeryBroker) ack(ctx context.Context, d *delivery) error {
+	return nil
+}
+
+func (b *celeryBroker) reject(ctx context.Context, d *delivery) error {
+	return nil
+}
+
+func (b *celeryBroker) requeue(ctx context.Context, d *delivery, data []byte) error {
+	return nil
+}
+
+func (b *celeryBroker) touch(ctx context.Context, d *delivery) error {
+	return nil
+}
+
+func (b *celeryBroker) close() error {
+	return nil
+}
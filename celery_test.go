@@ -0,0 +1,81 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestCeleryBody(t *testing.T) {
+	for payload, want := range map[string]string{
+		`[1,2]`:      `[[1,2],{}]`,
+		`{"x":1}`:    `[[],{"x":1}]`,
+		`not json`:   `[["not json"],{}]`,
+		`"a string"`: `[["a string"],{}]`,
+	} {
+		body, err := celeryBody([]byte(payload))
+		require.NoError(t, err)
+		var got []json.RawMessage
+		require.NoError(t, json.Unmarshal(body, &got))
+		require.Len(t, got, 3)
+		b, _ := json.Marshal(got[:2])
+		assert.JSONEq(t, want, string(b), payload)
+	}
+}
+
+func TestCelery(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("celery"),
+		WithDeliveryMode(Celery),
+	)
+	rdb := w.Redis()
+
+	m := job.NewMessage(mockMessage{Message: `[2,3]`}, job.AllowOption{Timeout: job.Time(time.Minute)})
+	assert.ErrorIs(t, w.Queue(&m), ErrNoCeleryTask)
+
+	qctx := ContextWithMetadata(ctx, Metadata{Headers: map[string]string{CeleryTaskHeader: "tasks.add"}})
+	id, err := w.QueueWithID(qctx, &m)
+	require.NoError(t, err)
+
+	msgs := rdb.LRange(ctx, "celery", 0, -1).Val()
+	require.Len(t, msgs, 1)
+	var msg struct {
+		Body        string         `json:"body"`
+		ContentType string         `json:"content-type"`
+		Headers     map[string]any `json:"headers"`
+		Properties  map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(msgs[0]), &msg))
+	assert.Equal(t, "application/json", msg.ContentType)
+	assert.Equal(t, "tasks.add", msg.Headers["task"])
+	assert.Equal(t, id, msg.Headers["id"])
+	assert.Equal(t, []any{nil, 60.0}, msg.Headers["timelimit"])
+	assert.Equal(t, "base64", msg.Properties["body_encoding"])
+	assert.Equal(t, map[string]any{"exchange": "", "routing_key": "celery"}, msg.Properties["delivery_info"])
+
+	body, err := base64.StdEncoding.DecodeString(msg.Body)
+	require.NoError(t, err)
+	var triple []json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &triple))
+	assert.JSONEq(t, `[2,3]`, string(triple[0]))
+	assert.JSONEq(t, `{}`, string(triple[1]))
+
+	// celery messages are left to the celery workers
+	_, err = w.Fetch(ctx, 10*time.Millisecond)
+	assert.ErrorIs(t, err, queue.ErrNoTaskInQueue)
+	assert.Equal(t, int64(1), rdb.LLen(ctx, "celery").Val())
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,123 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// pipeAcker is implemented by the brokers whose acknowledgements can be
+// sent in a transaction along with other commands.
+type pipeAcker interface {
+	ackPipe(ctx context.Context, pipe redis.Pipeliner, d *delivery)
+}
+
+var (
+	_ pipeAcker = (*listBroker)(nil)
+	_ pipeAcker = (*streamBroker)(nil)
+)
+
+// QueueChain queues the first of jobs and returns its ID. Every job carries
+// the next one, which the worker queues once the job succeeded, so the
+// jobs run one after the other, each only when the previous one succeeded.
+// In list and stream mode the next job is queued in the same transaction
+// as the acknowledgement of the previous one. A job that fails for good
+// ends the chain. With AckManual, the next job is queued when the handler
+// calls Ack: a job that succeeds without it is delivered again, and its
+// chain goes on once it is acknowledged.
+func (w *Worker) QueueChain(ctx context.Context, jobs ...core.TaskMessage) (string, error) {
+	if len(jobs) == 0 {
+		return "", errors.New("redisdb: chain has no jobs")
+	}
+
+	var (
+		next        []byte
+		nextChannel string
+	)
+	for i := len(jobs) - 1; i > 0; i-- {
+		env := w.newEnvelope(ctx, ulid.Make().String())
+		env.Next, env.NextChannel = next, nextChannel
+		channel, m, err := w.route(jobs[i], env)
+		if err != nil {
+			return "", err
+		}
+		data, err := w.encode(m, env)
+		if err != nil {
+			return "", err
+		}
+		next, nextChannel = data, channel
+	}
+
+	env := w.newEnvelope(ctx, ulid.Make().String())
+	env.Next, env.NextChannel = next, nextChannel
+	if err := w.send(ctx, jobs[0], env); err != nil {
+		return "", err
+	}
+	return env.ID, nil
+}
+
+// queueNext queues the next job of the chain of a job that succeeded. A
+// delivery not acknowledged yet is acknowledged in the same transaction,
+// so the next job is queued if and only if the job is acknowledged.
+func (w *Worker) queueNext(m *job.Message, d *delivery) {
+	ctx := context.Background()
+	if _, tracked := w.deliveries.Load(m); tracked {
+		if _, ok := w.broker.(pipeAcker); ok {
+			if err := w.ackNext(m); err != nil {
+				w.log.Error("failed to queue next job of chain", "channel", d.channel, "job_id", d.env.ID, "error", err)
+			}
+			return
+		}
+	}
+
+	if err := w.broker.push(ctx, w.rdb, d.env.NextChannel, w.restamp(d.env.Next)); err != nil {
+		w.log.Error("failed to queue next job of chain", "channel", d.channel, "job_id", d.env.ID, "error", err)
+		return
+	}
+	w.metrics.recordPublished(ctx, d.env.NextChannel)
+}
+
+// ackNext acknowledges the delivery of m and queues the next job of its
+// chain in one transaction. The next job is stamped now, so that its TTL
+// starts once it is queued.
+func (w *Worker) ackNext(m *job.Message) error {
+	v, ok := w.deliveries.LoadAndDelete(m)
+	if !ok {
+		return nil
+	}
+	d := v.(*delivery)
+	defer w.unlock(d.lock)
+
+	ctx := context.Background()
+	a, ok := w.broker.(pipeAcker)
+	if !ok {
+		// the next job is queued first, a job acknowledged without it
+		// would end the chain
+		if err := w.broker.push(ctx, w.rdb, d.env.NextChannel, w.restamp(d.env.Next)); err != nil {
+			return err
+		}
+		w.metrics.recordPublished(ctx, d.env.NextChannel)
+		if err := w.broker.ack(ctx, d); err != nil {
+			return err
+		}
+		w.metrics.recordSettled(ctx, d.channel, true)
+		w.dropJSONPayload(ctx, d.env)
+		return nil
+	}
+
+	_, err := w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		a.ackPipe(ctx, pipe, d)
+		return w.broker.push(ctx, pipe, d.env.NextChannel, w.restamp(d.env.Next))
+	})
+	if err != nil {
+		return err
+	}
+	w.metrics.recordSettled(ctx, d.channel, true)
+	w.metrics.recordPublished(ctx, d.env.NextChannel)
+	w.dropJSONPayload(ctx, d.env)
+	return nil
+}
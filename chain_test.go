@@ -0,0 +1,140 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestQueueChain(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "chain"
+	var steps []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			steps = append(steps, string(m.Payload()))
+			if string(m.Payload()) == "bad" {
+				return errors.New("failed")
+			}
+			return nil
+		}),
+	)
+	defer w.Shutdown()
+
+	msg := func(s string) core.TaskMessage {
+		m := job.NewMessage(mockMessage{Message: s})
+		return &m
+	}
+	_, err := w.QueueChain(ctx, msg("fetch"), msg("resize"), msg("upload"))
+	require.NoError(t, err)
+	_, err = w.QueueChain(ctx, msg("bad"), msg("never"))
+	require.NoError(t, err)
+
+	for {
+		task, err := w.Fetch(ctx, time.Second)
+		if errors.Is(err, queue.ErrNoTaskInQueue) {
+			break
+		}
+		require.NoError(t, err)
+		_ = w.Run(ctx, task)
+	}
+	assert.ElementsMatch(t, []string{"fetch", "resize", "upload", "bad"}, steps)
+	assert.Less(t, slices.Index(steps, "fetch"), slices.Index(steps, "resize"))
+	assert.Less(t, slices.Index(steps, "resize"), slices.Index(steps, "upload"))
+	assert.Zero(t, w.Redis().LLen(ctx, processingKey(channel, w.opts.consumerName)).Val())
+}
+
+func TestQueueChainManualAck(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "chain-manual"
+	var steps []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+		WithAckPolicy(AckManual),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			steps = append(steps, string(m.Payload()))
+			if string(m.Payload()) == "hold" {
+				return nil
+			}
+			return Ack(ctx)
+		}),
+	)
+	defer w.Shutdown()
+
+	msg := func(s string) core.TaskMessage {
+		m := job.NewMessage(mockMessage{Message: s})
+		return &m
+	}
+	_, err := w.QueueChain(ctx, msg("fetch"), msg("resize"))
+	require.NoError(t, err)
+	_, err = w.QueueChain(ctx, msg("hold"), msg("later"))
+	require.NoError(t, err)
+
+	for {
+		task, err := w.Fetch(ctx, time.Second)
+		if errors.Is(err, queue.ErrNoTaskInQueue) {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, w.Run(ctx, task))
+	}
+	// the job not acknowledged stays pending with the rest of its chain
+	assert.ElementsMatch(t, []string{"fetch", "resize", "hold"}, steps)
+	assert.Less(t, slices.Index(steps, "fetch"), slices.Index(steps, "resize"))
+	assert.Equal(t, int64(1), w.Redis().LLen(ctx, processingKey(channel, w.opts.consumerName)).Val())
+}
+
+func TestQueueChainJobTTL(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var steps []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("chain-ttl"),
+		WithDeliveryMode(List),
+		WithJobTTL(200*time.Millisecond),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			steps = append(steps, string(m.Payload()))
+			time.Sleep(300 * time.Millisecond)
+			return nil
+		}),
+	)
+	defer w.Shutdown()
+
+	msg := func(s string) core.TaskMessage {
+		m := job.NewMessage(mockMessage{Message: s})
+		return &m
+	}
+	_, err := w.QueueChain(ctx, msg("fetch"), msg("resize"))
+	require.NoError(t, err)
+
+	// each step has the TTL from when it is queued, not from the chain
+	for range 2 {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, w.Run(ctx, task))
+	}
+	assert.Equal(t, []string{"fetch", "resize"}, steps)
+}
@@ -0,0 +1,55 @@
+package redisdb
+
+import "sync/atomic"
+
+// ChannelStrategy selects the order in which a worker consuming several
+// channels looks for the next message.
+type ChannelStrategy int
+
+const (
+	// Priority always drains the channels in the order they were given,
+	// so a later channel is only read when the earlier ones are empty.
+	Priority ChannelStrategy = iota
+	// RoundRobin rotates the first channel looked at on every read.
+	RoundRobin
+)
+
+// channelSet holds the channels consumed by a worker.
+type channelSet struct {
+	names    []string
+	strategy ChannelStrategy
+	counter  uint32
+	// paused holds the channels skipped by next, see Inspector.Pause.
+	paused *pauseSet
+}
+
+func newChannelSet(names []string, strategy ChannelStrategy, paused *pauseSet) *channelSet {
+	return &channelSet{
+		names:    names,
+		strategy: strategy,
+		paused:   paused,
+	}
+}
+
+// next returns the channels in the order they should be read, without
+// the paused ones.
+func (c *channelSet) next() []string {
+	order := c.names
+	if c.strategy == RoundRobin && len(c.names) > 1 {
+		start := int(atomic.AddUint32(&c.counter, 1)-1) % len(c.names)
+		order = make([]string, 0, len(c.names))
+		order = append(order, c.names[start:]...)
+		order = append(order, c.names[:start]...)
+	}
+	if c.paused.empty() {
+		return order
+	}
+
+	active := make([]string, 0, len(order))
+	for _, name := range order {
+		if !c.paused.has(name) {
+			active = append(active, name)
+		}
+	}
+	return active
+}
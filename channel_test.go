@@ -0,0 +1,21 @@
+package redisdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelSetPriority(t *testing.T) {
+	c := newChannelSet([]string{"a", "b", "c"}, Priority, nil)
+	assert.Equal(t, []string{"a", "b", "c"}, c.next())
+	assert.Equal(t, []string{"a", "b", "c"}, c.next())
+}
+
+func TestChannelSetRoundRobin(t *testing.T) {
+	c := newChannelSet([]string{"a", "b", "c"}, RoundRobin, nil)
+	assert.Equal(t, []string{"a", "b", "c"}, c.next())
+	assert.Equal(t, []string{"b", "c", "a"}, c.next())
+	assert.Equal(t, []string{"c", "a", "b"}, c.next())
+	assert.Equal(t, []string{"a", "b", "c"}, c.next())
+}
@@ -0,0 +1,185 @@
+// Command redisqueue inspects and manages the channels of redisdb workers.
+//
+// Usage:
+//
+//	redisqueue [flags] <command> [n]
+//
+// Commands:
+//
+//	channels         list the declared channels
+//	stats            show the jobs of each state and the oldest pending age
+//	peek [n]         show the next n pending jobs of each channel
+//	requeue-dead [n] move n dead jobs of each channel back to it
+//	purge            delete the pending jobs
+//	pause            stop the workers from taking jobs
+//	resume           let the workers take jobs again
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-queue/redisdb"
+)
+
+func main() {
+	var (
+		addr      = flag.String("addr", "127.0.0.1:6379", "redis address")
+		db        = flag.Int("db", 0, "redis database")
+		username  = flag.String("username", "", "redis username")
+		password  = flag.String("password", os.Getenv("REDIS_PASSWORD"), "redis password, defaults to $REDIS_PASSWORD")
+		cluster   = flag.Bool("cluster", false, "connect to a redis cluster")
+		mode      = flag.String("mode", "list", "delivery mode of the channels: list or stream")
+		channels  = flag.String("channel", "golang-queue", "comma-separated channels")
+		namespace = flag.String("namespace", "", "key namespace of the workers")
+		group     = flag.String("group", "", "consumer group of stream workers")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: redisqueue [flags] channels|stats|peek [n]|requeue-dead [n]|purge|pause|resume\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []redisdb.Option{
+		redisdb.WithAddr(*addr),
+		redisdb.WithDB(*db),
+		redisdb.WithChannel(strings.Split(*channels, ",")...),
+	}
+	switch *mode {
+	case "list":
+		opts = append(opts, redisdb.WithDeliveryMode(redisdb.List))
+	case "stream":
+		opts = append(opts, redisdb.WithDeliveryMode(redisdb.Stream))
+	default:
+		fatalf("unsupported mode %q", *mode)
+	}
+	if *username != "" {
+		opts = append(opts, redisdb.WithUsername(*username))
+	}
+	if *password != "" {
+		opts = append(opts, redisdb.WithPassword(*password))
+	}
+	if *cluster {
+		opts = append(opts, redisdb.WithCluster())
+	}
+	if *namespace != "" {
+		opts = append(opts, redisdb.WithNamespace(*namespace))
+	}
+	if *group != "" {
+		opts = append(opts, redisdb.WithConsumerGroup(*group))
+	}
+
+	i := redisdb.NewInspector(opts...)
+	defer i.Close()
+
+	if err := run(context.Background(), i, flag.Args()); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func run(ctx context.Context, i *redisdb.Inspector, args []string) error {
+	n := 10
+	if len(args) > 1 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid count %q", args[1])
+		}
+		n = v
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer out.Flush()
+
+	switch args[0] {
+	case "channels":
+		infos, err := i.ListChannels(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "CHANNEL\tMODE\tCODEC\tOWNER\tDESCRIPTION")
+		for _, c := range infos {
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\n", c.Name, c.Mode, c.Codec, c.Owner, c.Description)
+		}
+	case "stats":
+		counts, err := i.Counts(ctx)
+		if err != nil {
+			return err
+		}
+		oldest := map[string]time.Time{}
+		jobs, err := i.List(ctx, redisdb.JobPending, 1)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			oldest[j.Channel] = j.EnqueuedAt
+		}
+		paused, err := i.Paused(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "CHANNEL\tPENDING\tACTIVE\tRETRY\tDEAD\tOLDEST PENDING\tPAUSED")
+		for _, c := range counts {
+			age := "-"
+			if t := oldest[c.Channel]; !t.IsZero() {
+				age = time.Since(t).Round(time.Second).String()
+			}
+			fmt.Fprintf(out, "%s\t%d\t%d\t%d\t%d\t%s\t%t\n",
+				c.Channel,
+				c.Jobs[redisdb.JobPending],
+				c.Jobs[redisdb.JobActive],
+				c.Jobs[redisdb.JobRetry],
+				c.Jobs[redisdb.JobDead],
+				age,
+				slices.Contains(paused, c.Channel),
+			)
+		}
+	case "peek":
+		jobs, err := i.List(ctx, redisdb.JobPending, n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "CHANNEL\tID\tENQUEUED\tPAYLOAD")
+		for _, j := range jobs {
+			enqueued := "-"
+			if !j.EnqueuedAt.IsZero() {
+				enqueued = j.EnqueuedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", j.Channel, j.ID, enqueued, strconv.Quote(string(j.Payload)))
+		}
+	case "requeue-dead":
+		moved, err := i.RequeueDead(ctx, n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "requeued %d jobs\n", moved)
+	case "purge":
+		purged, err := i.Purge(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "purged %d jobs\n", purged)
+	case "pause":
+		return i.Pause(ctx)
+	case "resume":
+		return i.Resume(ctx)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return nil
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "redisqueue: "+format+"\n", args...)
+	os.Exit(1)
+}
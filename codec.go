@@ -0,0 +1,174 @@
+package redisdb
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/oklog/ulid/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec serializes the jobs stored in Redis, with their envelope. All the
+// producers and workers of a channel must use the same codec.
+type Codec interface {
+	// Name identifies the codec in the topology and the channel catalog.
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes jobs as JSON, the default. Payloads are base64 encoded.
+type JSONCodec struct{}
+
+// Name implements Codec.
+func (JSONCodec) Name() string { return "json" }
+
+// Marshal implements Codec.
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal implements Codec.
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// MsgpackCodec encodes jobs as MessagePack, which stores binary payloads
+// as they are.
+type MsgpackCodec struct{}
+
+// Name implements Codec.
+func (MsgpackCodec) Name() string { return "msgpack" }
+
+// Marshal implements Codec.
+func (MsgpackCodec) Marshal(v any) ([]byte, error) { return msgpack.Marshal(v) }
+
+// Unmarshal implements Codec.
+func (MsgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+// wireMessage is the form of a job and its envelope in Redis. With the JSON
+// codec it matches the serialization of job.Message.
+type wireMessage struct {
+	Timeout     time.Duration     `json:"timeout" msgpack:"timeout"`
+	Body        []byte            `json:"body" msgpack:"body"`
+	RetryCount  int64             `json:"retry_count" msgpack:"retry_count"`
+	RetryDelay  time.Duration     `json:"retry_delay" msgpack:"retry_delay"`
+	RetryFactor float64           `json:"retry_factor" msgpack:"retry_factor"`
+	RetryMin    time.Duration     `json:"retry_min" msgpack:"retry_min"`
+	RetryMax    time.Duration     `json:"retry_max" msgpack:"retry_max"`
+	Jitter      bool              `json:"jitter" msgpack:"jitter"`
+	Version     int               `json:"v,omitempty" msgpack:"v,omitempty"`
+	ID          string            `json:"id,omitempty" msgpack:"id,omitempty"`
+	EnqueuedAt  int64             `json:"enqueued_at,omitempty" msgpack:"enqueued_at,omitempty"`
+	Attempts    int               `json:"attempts,omitempty" msgpack:"attempts,omitempty"`
+	ContentType string            `json:"content_type,omitempty" msgpack:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty" msgpack:"headers,omitempty"`
+	Trace       map[string]string `json:"trace,omitempty" msgpack:"trace,omitempty"`
+	PayloadKey  string            `json:"payload_key,omitempty" msgpack:"payload_key,omitempty"`
+	ExpiresAt   int64             `json:"expires_at,omitempty" msgpack:"expires_at,omitempty"`
+	Group       string            `json:"group,omitempty" msgpack:"group,omitempty"`
+	Next        []byte            `json:"next,omitempty" msgpack:"next,omitempty"`
+	NextChannel string            `json:"next_channel,omitempty" msgpack:"next_channel,omitempty"`
+}
+
+// toMessage returns the job of a queued task. Tasks other than job.Message
+// are expected to serialize like one.
+func toMessage(task core.TaskMessage) (*job.Message, error) {
+	if m, ok := task.(*job.Message); ok {
+		return m, nil
+	}
+	var m job.Message
+	if err := json.Unmarshal(task.Bytes(), &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// encode serializes a job with its envelope.
+func (w *Worker) encode(m *job.Message, env envelope) ([]byte, error) {
+	var enqueuedAt, expiresAt int64
+	if !env.EnqueuedAt.IsZero() {
+		enqueuedAt = env.EnqueuedAt.UnixMilli()
+	}
+	if !env.ExpiresAt.IsZero() {
+		expiresAt = env.ExpiresAt.UnixMilli()
+	}
+	return w.opts.codec.Marshal(wireMessage{
+		Timeout:     m.Timeout,
+		Body:        m.Body,
+		RetryCount:  m.RetryCount,
+		RetryDelay:  m.RetryDelay,
+		RetryFactor: m.RetryFactor,
+		RetryMin:    m.RetryMin,
+		RetryMax:    m.RetryMax,
+		Jitter:      m.Jitter,
+		Version:     env.Version,
+		ID:          env.ID,
+		EnqueuedAt:  enqueuedAt,
+		Attempts:    env.Attempts,
+		ContentType: env.ContentType,
+		Headers:     env.Headers,
+		Trace:       env.Trace,
+		PayloadKey:  env.PayloadKey,
+		ExpiresAt:   expiresAt,
+		Group:       env.Group,
+		Next:        env.Next,
+		NextChannel: env.NextChannel,
+	})
+}
+
+// decode reads a job serialized by encode.
+func (w *Worker) decode(data []byte) (*job.Message, envelope, error) {
+	var wm wireMessage
+	if err := w.opts.codec.Unmarshal(data, &wm); err != nil {
+		return nil, envelope{}, err
+	}
+	m := &job.Message{
+		Timeout:     wm.Timeout,
+		Body:        wm.Body,
+		RetryCount:  wm.RetryCount,
+		RetryDelay:  wm.RetryDelay,
+		RetryFactor: wm.RetryFactor,
+		RetryMin:    wm.RetryMin,
+		RetryMax:    wm.RetryMax,
+		Jitter:      wm.Jitter,
+	}
+	env := envelope{
+		Version:     wm.Version,
+		ID:          wm.ID,
+		Attempts:    wm.Attempts,
+		ContentType: wm.ContentType,
+		Headers:     wm.Headers,
+		Trace:       wm.Trace,
+		PayloadKey:  wm.PayloadKey,
+		Group:       wm.Group,
+		Next:        wm.Next,
+		NextChannel: wm.NextChannel,
+	}
+	if wm.EnqueuedAt > 0 {
+		env.EnqueuedAt = time.UnixMilli(wm.EnqueuedAt)
+	} else if id, err := ulid.ParseStrict(wm.ID); err == nil {
+		// the envelope of older releases has no enqueue time
+		env.EnqueuedAt = ulid.Time(id.Time())
+	}
+	if wm.ExpiresAt > 0 {
+		env.ExpiresAt = time.UnixMilli(wm.ExpiresAt)
+	}
+	return m, env, nil
+}
+
+// reseal returns the message of a delivery queued again after its runs.
+func (w *Worker) reseal(d *delivery) []byte {
+	m, env, err := w.decode(d.data)
+	if err != nil {
+		return d.data
+	}
+	env.Attempts += int(atomic.LoadInt32(&d.attempts))
+	if d.retryCount != nil {
+		m.RetryCount = *d.retryCount
+	}
+	data, err := w.encode(m, env)
+	if err != nil {
+		return d.data
+	}
+	return data
+}
@@ -0,0 +1,58 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestCodec(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, codec := range []Codec{JSONCodec{}, MsgpackCodec{}} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			for _, mode := range []DeliveryMode{List, Stream} {
+				channel := "codec-" + codec.Name() + "-" + mode.String()
+				var jobID string
+				w := NewWorker(
+					WithAddr(endpoint),
+					WithChannel(channel),
+					WithDeliveryMode(mode),
+					WithCodec(codec),
+					WithRunFunc(func(ctx context.Context, _ core.TaskMessage) error {
+						jobID = JobIDFromContext(ctx)
+						return nil
+					}),
+				)
+				assert.Equal(t, codec.Name(), w.Topology().Codec)
+
+				payload := []byte{0x00, 0xff, 0x10}
+				m := job.NewMessage(mockMessage{Message: string(payload)}, job.AllowOption{
+					RetryCount: job.Int64(2),
+					Timeout:    job.Time(time.Minute),
+				})
+				id, err := w.QueueWithID(ctx, &m)
+				require.NoError(t, err)
+
+				task, err := w.Fetch(ctx, time.Second)
+				require.NoError(t, err)
+				got := task.(*job.Message)
+				assert.Equal(t, payload, got.Payload())
+				assert.Equal(t, int64(2), got.RetryCount)
+				assert.Equal(t, time.Minute, got.Timeout)
+
+				assert.NoError(t, w.Run(ctx, task))
+				assert.Equal(t, id, jobID)
+				assert.NoError(t, w.Shutdown())
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package redisdb
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	clientKey   struct{}
+	channelKey  struct{}
+	jobIDKey    struct{}
+	metadataKey struct{}
+)
+
+// Client returns the Redis client of the worker running the job, so
+// handlers can reuse its connection pool for their own commands. It
+// returns nil when ctx does not come from a worker.
+func Client(ctx context.Context) redis.Cmdable {
+	rdb, _ := ctx.Value(clientKey{}).(redis.Cmdable)
+	return rdb
+}
+
+// JobIDFromContext returns the ID of the running job, see QueueWithID. It
+// is empty for messages queued by producers that do not assign IDs.
+func JobIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(jobIDKey{}).(string)
+	return id
+}
+
+// ChannelFromContext returns the channel the running job was received
+// from. With pattern subscriptions this is the concrete channel name.
+func ChannelFromContext(ctx context.Context) string {
+	channel, _ := ctx.Value(channelKey{}).(string)
+	return channel
+}
@@ -0,0 +1,154 @@
+package redisdb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// deadLetterCheckInterval is how often the dead letter queues are checked
+// against the thresholds of WithDeadLetterAlert.
+const deadLetterCheckInterval = time.Minute
+
+// DeadLetterStats describes the dead letter queue of a channel.
+type DeadLetterStats struct {
+	Channel string `json:"channel"`
+	// Size is the number of dead-lettered messages.
+	Size int64 `json:"size"`
+	// OldestAge is how long ago the oldest message was dead-lettered.
+	OldestAge time.Duration `json:"oldest_age"`
+}
+
+func deadKey(channel string) string {
+	return channel + ":dead"
+}
+
+// deadLetter appends a message that failed for good to the dead letter
+// stream of its channel. The entry ID records when it was dead-lettered.
+func deadLetter(ctx context.Context, pipe redis.Pipeliner, channel string, data []byte) {
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: deadKey(channel),
+		Values: map[string]interface{}{streamPayloadField: data},
+	})
+}
+
+// DeadLetterStats returns the size and age of the dead letter queue of every
+// consumed channel. Pub/sub workers have no dead letter queue.
+func (w *Worker) DeadLetterStats(ctx context.Context) ([]DeadLetterStats, error) {
+	if w.opts.mode == PubSub {
+		return nil, nil
+	}
+
+	stats := make([]DeadLetterStats, 0, len(w.opts.channels))
+	for _, channel := range w.opts.channels {
+		s := DeadLetterStats{Channel: channel}
+		size, err := w.rdb.XLen(ctx, deadKey(channel)).Result()
+		if err != nil {
+			return nil, err
+		}
+		s.Size = size
+
+		if size > 0 {
+			oldest, err := w.rdb.XRangeN(ctx, deadKey(channel), "-", "+", 1).Result()
+			if err != nil {
+				return nil, err
+			}
+			if len(oldest) > 0 {
+				s.OldestAge = time.Since(streamIDTime(oldest[0].ID))
+			}
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+// requeueDeadScript moves the oldest entries of a dead letter stream back
+// to their channel, a stream when ARGV[3] is "1" and a list otherwise.
+var requeueDeadScript = newQueueScript("requeue_dead", `
+local entries = redis.call("XRANGE", KEYS[1], "-", "+", "COUNT", ARGV[1])
+for _, e in ipairs(entries) do
+  local fields = e[2]
+  for j = 1, #fields, 2 do
+    if fields[j] == ARGV[2] then
+      if ARGV[3] == "1" then
+        redis.call("XADD", KEYS[2], "*", ARGV[2], fields[j + 1])
+      else
+        redis.call("LPUSH", KEYS[2], fields[j + 1])
+      end
+    end
+  end
+  redis.call("XDEL", KEYS[1], e[1])
+end
+return #entries
+`)
+
+// RequeueDead moves up to n of the oldest dead-lettered messages of every
+// consumed channel back to the channel, where they are run again, and
+// returns how many were moved.
+func (w *Worker) RequeueDead(ctx context.Context, n int) (int64, error) {
+	if (w.opts.mode != List && w.opts.mode != Stream) || n <= 0 {
+		return 0, nil
+	}
+
+	stream := "0"
+	if w.opts.mode == Stream {
+		stream = "1"
+	}
+	var moved int64
+	for _, channel := range w.opts.channels {
+		c, err := requeueDeadScript.run(ctx, w.rdb, w.functions,
+			[]string{deadKey(channel), channel},
+			n, streamPayloadField, stream,
+		).Int64()
+		if err != nil {
+			return moved, err
+		}
+		moved += c
+	}
+	return moved, nil
+}
+
+// checkDeadLetters calls the alert func for every dead letter queue over
+// one of the configured thresholds.
+func (w *Worker) checkDeadLetters(ctx context.Context) {
+	stats, err := w.DeadLetterStats(ctx)
+	if err != nil {
+		w.log.Error("failed to read dead letter stats", "error", err)
+		return
+	}
+
+	alert := w.opts.deadLetterAlert
+	for _, s := range stats {
+		if (alert.maxSize > 0 && s.Size > alert.maxSize) ||
+			(alert.maxAge > 0 && s.OldestAge > alert.maxAge) {
+			alert.fn(s)
+		}
+	}
+}
+
+func (w *Worker) watchDeadLetters() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(deadLetterCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.checkDeadLetters(context.Background())
+		}
+	}
+}
+
+// streamIDTime returns the time encoded in a stream entry ID.
+func streamIDTime(id string) time.Time {
+	ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,64 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestStreamIDTime(t *testing.T) {
+	assert.Equal(t, time.UnixMilli(1700000000123), streamIDTime("1700000000123-4"))
+	assert.True(t, streamIDTime("invalid").IsZero())
+}
+
+func TestDeadLetterStats(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			channel := "dead-" + mode.String()
+			var alerts []DeadLetterStats
+			w := NewWorker(
+				WithAddr(endpoint),
+				WithChannel(channel),
+				WithDeliveryMode(mode),
+				WithDeadLetterAlert(1, 0, func(s DeadLetterStats) {
+					alerts = append(alerts, s)
+				}),
+				WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+					return errors.New("failed")
+				}),
+			)
+
+			for i := 0; i < 2; i++ {
+				m := job.NewMessage(mockMessage{Message: "foo"})
+				assert.NoError(t, w.Queue(&m))
+			}
+			_, err := w.DrainOnce(ctx)
+			assert.Error(t, err)
+			time.Sleep(10 * time.Millisecond)
+
+			stats, err := w.DeadLetterStats(ctx)
+			require.NoError(t, err)
+			require.Len(t, stats, 1)
+			assert.Equal(t, channel, stats[0].Channel)
+			assert.Equal(t, int64(2), stats[0].Size)
+			assert.Greater(t, stats[0].OldestAge, time.Duration(0))
+
+			w.checkDeadLetters(ctx)
+			require.Len(t, alerts, 1)
+			assert.Equal(t, int64(2), alerts[0].Size)
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
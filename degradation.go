@@ -0,0 +1,163 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// replicationLagThreshold is the replica lag above which replication is
+// reported as degraded.
+const replicationLagThreshold = 10 * time.Second
+
+// DegradationKind names a degraded state of a worker.
+type DegradationKind string
+
+const (
+	// DegradedConnection means the last reads from Redis failed.
+	DegradedConnection DegradationKind = "connection"
+	// DegradedDeadLetters means a dead letter queue is over the thresholds
+	// of WithDeadLetterAlert.
+	DegradedDeadLetters DegradationKind = "dead_letters"
+	// DegradedReclaimBacklog means messages are held by consumers that
+	// stopped, and wait to be recovered.
+	DegradedReclaimBacklog DegradationKind = "reclaim_backlog"
+	// DegradedReplication means a replica is disconnected or lagging.
+	DegradedReplication DegradationKind = "replication"
+)
+
+// Degradation describes one degraded state.
+type Degradation struct {
+	Kind    DegradationKind `json:"kind"`
+	Channel string          `json:"channel,omitempty"`
+	Detail  string          `json:"detail"`
+}
+
+// Degradations returns the degraded states the worker is currently in. An
+// empty result means the worker is fully healthy.
+func (w *Worker) Degradations(ctx context.Context) ([]Degradation, error) {
+	var res []Degradation
+
+	if n := atomic.LoadInt32(&w.popFailures); n > 0 {
+		res = append(res, Degradation{
+			Kind:   DegradedConnection,
+			Detail: fmt.Sprintf("%d consecutive reads failed", n),
+		})
+	}
+	if err := w.rdb.Ping(ctx).Err(); err != nil {
+		return append(res, Degradation{
+			Kind:   DegradedConnection,
+			Detail: err.Error(),
+		}), nil
+	}
+
+	if w.opts.mode != PubSub {
+		stats, err := w.DeadLetterStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		alert := w.opts.deadLetterAlert
+		for _, s := range stats {
+			if (alert.maxSize > 0 && s.Size > alert.maxSize) ||
+				(alert.maxAge > 0 && s.OldestAge > alert.maxAge) {
+				res = append(res, Degradation{
+					Kind:    DegradedDeadLetters,
+					Channel: s.Channel,
+					Detail:  fmt.Sprintf("%d messages, oldest %s", s.Size, s.OldestAge.Round(time.Second)),
+				})
+			}
+		}
+
+		for _, channel := range w.opts.channels {
+			n, err := w.reclaimBacklog(ctx, channel)
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				res = append(res, Degradation{
+					Kind:    DegradedReclaimBacklog,
+					Channel: channel,
+					Detail:  fmt.Sprintf("%d messages held by stopped consumers", n),
+				})
+			}
+		}
+	}
+
+	detail, err := w.replicationDegradation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if detail != "" {
+		res = append(res, Degradation{Kind: DegradedReplication, Detail: detail})
+	}
+
+	return res, nil
+}
+
+// reclaimBacklog counts the messages of channel held by consumers that
+// stopped: stream entries idle for longer than the consumer TTL, or the
+// processing lists of list consumers without heartbeats.
+func (w *Worker) reclaimBacklog(ctx context.Context, channel string) (int64, error) {
+	if w.opts.mode == Stream {
+		pending, err := staleEntries(ctx, w.rdb, w.opts.flavor, channel, w.opts.consumerGroup, 1000)
+		return int64(len(pending)), err
+	}
+
+	expired := strconv.FormatInt(time.Now().Add(-consumerTTL).Unix(), 10)
+	names, err := w.rdb.ZRangeByScore(ctx, consumersKey(channel), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + expired,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, name := range names {
+		size, err := w.rdb.LLen(ctx, processingKey(channel, name)).Result()
+		if err != nil {
+			return 0, err
+		}
+		n += size
+	}
+	return n, nil
+}
+
+// replicationDegradation describes the replication issues reported by the
+// server the worker talks to, if any. Servers that do not report their
+// replication, such as some managed services, are not checked.
+func (w *Worker) replicationDegradation(ctx context.Context) (string, error) {
+	info, err := w.rdb.Info(ctx, "replication").Result()
+	if isReplyError(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var issues []string
+	for _, line := range strings.Split(info, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch {
+		case key == "master_link_status" && value != "up":
+			issues = append(issues, "link to master is "+value)
+		case strings.HasPrefix(key, "slave") && strings.Contains(value, "lag="):
+			// slave0:ip=...,port=...,state=online,offset=...,lag=0
+			for _, field := range strings.Split(value, ",") {
+				if lag, ok := strings.CutPrefix(field, "lag="); ok {
+					if s, err := strconv.Atoi(lag); err == nil && time.Duration(s)*time.Second > replicationLagThreshold {
+						issues = append(issues, fmt.Sprintf("%s lags %ss behind", key, lag))
+					}
+				}
+			}
+		}
+	}
+	return strings.Join(issues, ", "), nil
+}
@@ -0,0 +1,53 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestDegradations(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("degraded"),
+		WithDeliveryMode(List),
+		WithDeadLetterAlert(1, 0, func(DeadLetterStats) {}),
+	)
+
+	res, err := w.Degradations(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	// a consumer that stopped while holding a message
+	rdb := w.Redis()
+	assert.NoError(t, rdb.ZAdd(ctx, consumersKey("degraded"), redis.Z{
+		Score:  float64(time.Now().Add(-time.Minute).Unix()),
+		Member: "gone",
+	}).Err())
+	assert.NoError(t, rdb.LPush(ctx, processingKey("degraded", "gone"), "foo").Err())
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, rdb.XAdd(ctx, &redis.XAddArgs{
+			Stream: deadKey("degraded"),
+			Values: map[string]any{streamPayloadField: "bar"},
+		}).Err())
+	}
+
+	res, err = w.Degradations(ctx)
+	require.NoError(t, err)
+	kinds := make([]DegradationKind, 0, len(res))
+	for _, d := range res {
+		assert.Equal(t, "degraded", d.Channel)
+		kinds = append(kinds, d.Kind)
+	}
+	assert.ElementsMatch(t, []DegradationKind{DegradedDeadLetters, DegradedReclaimBacklog}, kinds)
+	assert.NoError(t, w.Shutdown())
+}
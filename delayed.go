@@ -0,0 +1,212 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// delayedPollInterval is how often messages whose delay elapsed are
+	// moved back to their channel.
+	delayedPollInterval = time.Second
+	// delayedBatchSize bounds the messages moved by one poll.
+	delayedBatchSize = 100
+	// delayedSeparator ends the unique prefix of a delayed member.
+	delayedSeparator = "|"
+	// delayedIDSeparator separates the token of a delayed member from the
+	// hex encoded ID of its job, in its prefix.
+	delayedIDSeparator = "."
+)
+
+// moveDelayedScript moves up to ARGV[2] messages of the delayed set KEYS[1]
+// that are due to the channel KEYS[2], the way the delivery mode ARGV[1]
+// stores them. When ARGV[4] is not 0, streams are capped to about ARGV[4]
+// entries, and pub/sub messages are kept in the backlog KEYS[3] of at most
+// ARGV[4] messages, by time. Members are prefixed to keep identical payloads apart,
+// and the prefix ends with the ID of their job, whose entry is removed
+// from the index KEYS[4].
+var moveDelayedScript = newQueueScript("move_delayed", `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now, 'LIMIT', 0, tonumber(ARGV[2]))
+for _, member in ipairs(due) do
+  redis.call('ZREM', KEYS[1], member)
+  local sep = string.find(member, '|', 1, true)
+  local prefix = string.sub(member, 1, sep - 1)
+  local dot = string.find(prefix, '.', 1, true)
+  if dot then
+    local id = string.sub(prefix, dot + 1)
+    if redis.call('HGET', KEYS[4], id) == member then
+      redis.call('HDEL', KEYS[4], id)
+    end
+  end
+  local data = string.sub(member, sep + 1)
+  if ARGV[1] == 'list' then
+    redis.call('LPUSH', KEYS[2], data)
+  elseif ARGV[1] == 'stream' and ARGV[4] ~= '0' then
+    redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[4], '*', ARGV[3], data)
+  elseif ARGV[1] == 'stream' then
+    redis.call('XADD', KEYS[2], '*', ARGV[3], data)
+  else
+    if ARGV[4] ~= '0' then
+      redis.call('ZADD', KEYS[3], now, data)
+      redis.call('ZREMRANGEBYRANK', KEYS[3], 0, -tonumber(ARGV[4]) - 1)
+    end
+    redis.call('PUBLISH', KEYS[2], data)
+  end
+end
+return #due
+`)
+
+func delayedKey(channel string) string {
+	return channel + ":delayed"
+}
+
+// delayedIDsKey is the hash of the delayed members of channel by the hex
+// encoded ID of their job, see Reschedule and Cancel.
+func delayedIDsKey(channel string) string {
+	return channel + ":delayed:ids"
+}
+
+// schedule stores a message to be delivered on channel at the given time,
+// indexed by the ID of its job.
+func (w *Worker) schedule(ctx context.Context, channel string, data []byte, at time.Time) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	id := w.delayedJobID(data)
+	if id == "" {
+		return w.rdb.ZAdd(ctx, delayedKey(channel), redis.Z{
+			Score:  float64(at.UnixMilli()),
+			Member: token + delayedSeparator + string(data),
+		}).Err()
+	}
+	field := hex.EncodeToString([]byte(id))
+	member := token + delayedIDSeparator + field + delayedSeparator + string(data)
+	_, err = w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, delayedKey(channel), redis.Z{Score: float64(at.UnixMilli()), Member: member})
+		pipe.HSet(ctx, delayedIDsKey(channel), field, member)
+		return nil
+	})
+	return err
+}
+
+// delayedMember returns the member of the delayed set of channel holding
+// the job jobID, or "" when it has none.
+func (w *Worker) delayedMember(ctx context.Context, channel, jobID string) (string, error) {
+	member, err := w.rdb.HGet(ctx, delayedIDsKey(channel), hex.EncodeToString([]byte(jobID))).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return member, err
+}
+
+// retrier is implemented by the brokers that schedule redeliveries on
+// their own.
+type retrier interface {
+	// retry settles a delivery whose message is delivered again at the
+	// given time.
+	retry(ctx context.Context, d *delivery, at time.Time) error
+}
+
+// redeliver settles a delivery and schedules its message to be delivered
+// again after delay.
+func (w *Worker) redeliver(d *delivery, delay time.Duration) error {
+	ctx := context.Background()
+	w.metrics.recordSettled(ctx, d.channel, false)
+	w.metrics.recordRedelivered(ctx, d.channel)
+	data := w.reseal(d)
+	if delay <= 0 {
+		return w.broker.requeue(ctx, d, data)
+	}
+	if r, ok := w.broker.(retrier); ok {
+		return r.retry(ctx, d, time.Now().Add(delay))
+	}
+	if err := w.schedule(ctx, w.opts.retryChannel(d.channel), data, time.Now().Add(delay)); err != nil {
+		return err
+	}
+	return w.broker.ack(ctx, d)
+}
+
+// moveDelayed moves the messages whose delay elapsed back to the consumed
+// channels.
+func (w *Worker) moveDelayed(ctx context.Context) error {
+	var maxLen int64
+	switch {
+	case w.opts.broadcast:
+		maxLen = broadcastMaxLen
+	case w.opts.mode == PubSub && w.opts.pubsubBacklog:
+		maxLen = pubsubBacklogMaxLen
+	}
+	for _, channel := range w.opts.channels {
+		for {
+			n, err := moveDelayedScript.run(ctx, w.rdb, w.functions,
+				[]string{delayedKey(channel), channel, backlogKey(channel), delayedIDsKey(channel)},
+				w.opts.mode.String(), delayedBatchSize, streamPayloadField, maxLen,
+			).Int()
+			if err != nil {
+				return err
+			}
+			if n < delayedBatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Worker) watchDelayed() {
+	defer w.wg.Done()
+	if w.keyspace != nil {
+		w.watchDelayedKeyspace()
+		return
+	}
+	ticker := time.NewTicker(delayedPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.moveDelayed(context.Background()); err != nil {
+				w.log.Error("failed to move delayed messages", "error", err)
+			}
+		}
+	}
+}
+
+// watchDelayedKeyspace moves the delayed messages when the first one is
+// due, woken by the keyspace notifications of the delayed sets when
+// messages are scheduled, and at least every delayedPollInterval.
+func (w *Worker) watchDelayedKeyspace() {
+	for {
+		woken := w.keyspace.delayed.wait()
+		ctx := context.Background()
+		if err := w.moveDelayed(ctx); err != nil {
+			w.log.Error("failed to move delayed messages", "error", err)
+		}
+
+		wait := delayedPollInterval
+		next, err := w.nextDelayed(ctx)
+		if err != nil {
+			w.log.Error("failed to read delayed messages", "error", err)
+		} else if !next.IsZero() {
+			wait = min(max(time.Until(next), 0), wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case <-woken:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
@@ -0,0 +1,132 @@
+package redisdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// depthCacheTTL is how long the queue depth read by Capacity and Usage is
+// reused, so that frequent metric scrapes do not load Redis.
+const depthCacheTTL = time.Second
+
+// depthScanLimit bounds the entries counted when the server does not report
+// the lag of a consumer group.
+const depthScanLimit = 10000
+
+// depth caches the number of messages held in Redis for the channels.
+type depth struct {
+	mu       sync.Mutex
+	at       time.Time
+	waiting  int64
+	inflight int64
+}
+
+// Capacity returns the number of messages held in Redis for the consumed
+// channels, waiting or taken by a worker. The value is cached for a second.
+// Pub/sub channels hold no messages.
+func (w *Worker) Capacity() int {
+	waiting, inflight := w.queueDepth()
+	return int(waiting + inflight)
+}
+
+// Usage returns the number of messages of the consumed channels taken by
+// workers and not settled yet, across all the workers. The value is cached
+// for a second.
+func (w *Worker) Usage() int {
+	_, inflight := w.queueDepth()
+	return int(inflight)
+}
+
+func (w *Worker) queueDepth() (int64, int64) {
+	w.depth.mu.Lock()
+	defer w.depth.mu.Unlock()
+
+	if w.opts.mode == PubSub || time.Since(w.depth.at) < depthCacheTTL {
+		return w.depth.waiting, w.depth.inflight
+	}
+
+	ctx := context.Background()
+	var waiting, inflight int64
+	for _, channel := range w.opts.channels {
+		var (
+			wn, in int64
+			err    error
+		)
+		if w.opts.mode == Stream {
+			wn, in, err = w.streamDepth(ctx, channel)
+		} else {
+			wn, in, err = w.listDepth(ctx, channel)
+		}
+		if err != nil {
+			// keep reporting the last known depth
+			w.log.Error("failed to read the queue depth", "channel", channel, "error", err)
+			return w.depth.waiting, w.depth.inflight
+		}
+		waiting += wn
+		inflight += in
+	}
+
+	w.depth.at = time.Now()
+	w.depth.waiting = waiting
+	w.depth.inflight = inflight
+	return waiting, inflight
+}
+
+func (w *Worker) listDepth(ctx context.Context, channel string) (int64, int64, error) {
+	consumers, err := w.rdb.ZRange(ctx, consumersKey(channel), 0, -1).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		waiting    *redis.IntCmd
+		processing = make([]*redis.IntCmd, 0, len(consumers))
+	)
+	_, err = w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		waiting = pipe.LLen(ctx, channel)
+		for _, name := range consumers {
+			processing = append(processing, pipe.LLen(ctx, processingKey(channel, name)))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var inflight int64
+	for _, cmd := range processing {
+		inflight += cmd.Val()
+	}
+	return waiting.Val(), inflight, nil
+}
+
+// streamDepth reads the entries not delivered to the consumer group yet and
+// the entries pending in the group. Servers before Redis 7 do not report the
+// lag of groups, the entries are then counted up to depthScanLimit.
+func (w *Worker) streamDepth(ctx context.Context, channel string) (int64, int64, error) {
+	groups, err := w.rdb.XInfoGroups(ctx, channel).Result()
+	if isReplyError(err) {
+		// the stream does not exist yet
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, g := range groups {
+		if g.Name != w.opts.consumerGroup {
+			continue
+		}
+		if g.Lag > 0 {
+			return g.Lag, g.Pending, nil
+		}
+		entries, err := w.rdb.XRangeN(ctx, channel, "("+g.LastDeliveredID, "+", depthScanLimit).Result()
+		return int64(len(entries)), g.Pending, err
+	}
+
+	// no worker of the group started yet, every entry is waiting
+	n, err := w.rdb.XLen(ctx, channel).Result()
+	return n, 0, err
+}
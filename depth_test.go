@@ -0,0 +1,46 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestCapacityUsage(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			w := NewWorker(
+				WithAddr(endpoint),
+				WithChannel("depth-"+mode.String()),
+				WithDeliveryMode(mode),
+			)
+
+			for _, v := range []string{"a", "b", "c"} {
+				m := job.NewMessage(mockMessage{Message: v})
+				require.NoError(t, w.Queue(&m))
+			}
+			task, err := w.Fetch(ctx, time.Second)
+			require.NoError(t, err)
+
+			assert.Equal(t, 3, w.Capacity())
+			assert.Equal(t, 1, w.Usage())
+
+			// cached
+			assert.NoError(t, w.Run(ctx, task))
+			assert.Equal(t, 1, w.Usage())
+			time.Sleep(depthCacheTTL)
+			assert.Equal(t, 2, w.Capacity())
+			assert.Equal(t, 0, w.Usage())
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
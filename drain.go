@@ -0,0 +1,109 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+)
+
+const (
+	// drainWait is how long ProcessN waits for a message before it
+	// considers the channels empty.
+	drainWait = 100 * time.Millisecond
+	// inflightPollInterval is how often Shutdown checks whether the
+	// fetched messages are settled when draining.
+	inflightPollInterval = 10 * time.Millisecond
+)
+
+// ProcessN fetches and runs up to n jobs one after another, without a
+// queue, and returns how many were run. It stops early once no message is
+// available or ctx is done. Failed jobs are retried according to their
+// retry count, the errors of jobs that still failed are joined in the
+// returned error.
+func (w *Worker) ProcessN(ctx context.Context, n int) (int, error) {
+	var errs []error
+	processed := 0
+	for processed < n {
+		task, err := w.Fetch(ctx, drainWait)
+		if errors.Is(err, queue.ErrNoTaskInQueue) ||
+			errors.Is(err, context.Canceled) ||
+			errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		processed++
+		if err := w.process(ctx, task); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return processed, errors.Join(errs...)
+}
+
+// DrainOnce runs jobs until no message is available, see ProcessN.
+func (w *Worker) DrainOnce(ctx context.Context) (int, error) {
+	return w.ProcessN(ctx, math.MaxInt)
+}
+
+// waitInflight waits until every message handed out by Fetch is settled,
+// for up to timeout when it is positive. It returns the number of messages
+// left unsettled.
+func (w *Worker) waitInflight(timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	for {
+		n := w.inflight()
+		if n == 0 || (timeout > 0 && time.Now().After(deadline)) {
+			return n
+		}
+		time.Sleep(inflightPollInterval)
+	}
+}
+
+func (w *Worker) inflight() int {
+	n := 0
+	w.deliveries.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// process runs a task the way the queue does: within the job timeout and
+// retrying until it succeeds or has no attempts left.
+func (w *Worker) process(ctx context.Context, task core.TaskMessage) error {
+	m, ok := task.(*job.Message)
+	if !ok {
+		return w.Run(ctx, task)
+	}
+
+	if m.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+		defer cancel()
+	}
+
+	backoff := w.retryBackoff(m)
+	for attempt := 1; ; attempt++ {
+		err := w.Run(ctx, m)
+		if err == nil || m.RetryCount == 0 {
+			return err
+		}
+		m.RetryCount--
+
+		select {
+		case <-time.After(backoff.Delay(attempt)):
+		case <-ctx.Done():
+			w.settle(m, ctx.Err())
+			return ctx.Err()
+		}
+	}
+}
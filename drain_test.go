@@ -0,0 +1,180 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestProcessN(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var rets []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("drain"),
+		WithDeliveryMode(Stream),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			rets = append(rets, string(m.Payload()))
+			return nil
+		}),
+	)
+
+	for _, v := range []string{"a", "b", "c"} {
+		m := job.NewMessage(mockMessage{Message: v})
+		assert.NoError(t, w.Queue(&m))
+	}
+
+	n, err := w.ProcessN(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"a", "b"}, rets)
+
+	n, err = w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"a", "b", "c"}, rets)
+
+	n, err = w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestProcessNRetry(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	attempts := 0
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("drain-retry"),
+		WithDeliveryMode(List),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			attempts++
+			return errors.New("failed")
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{
+		RetryCount: job.Int64(2),
+	})
+	assert.NoError(t, w.Queue(&m))
+
+	n, err := w.DrainOnce(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, int64(1), w.Redis().XLen(ctx, deadKey("drain-retry")).Val())
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestDrainOnShutdown(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var rets []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("drain-shutdown"),
+		WithDeliveryMode(List),
+		WithConsumerName("drainer"),
+		WithDrainOnShutdown(),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			time.Sleep(200 * time.Millisecond)
+			rets = append(rets, string(m.Payload()))
+			return nil
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.Queue(&m))
+	task, err := w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx, task)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// Shutdown waits for the running job to be acknowledged
+	assert.NoError(t, w.Shutdown())
+	assert.Equal(t, []string{"foo"}, rets)
+	assert.NoError(t, <-done)
+
+	check := NewWorker(WithAddr(endpoint))
+	assert.Equal(t, int64(0), check.Redis().LLen(ctx, processingKey("drain-shutdown", "drainer")).Val())
+	assert.Equal(t, int64(0), check.Redis().LLen(ctx, "drain-shutdown").Val())
+	assert.NoError(t, check.Shutdown())
+}
+
+func TestStreamShutdownRequeuesBuffered(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("buffered-a", "buffered-b"),
+		WithDeliveryMode(Stream),
+	)
+	check := NewWorker(WithAddr(endpoint))
+
+	for _, channel := range []string{"buffered-a", "buffered-b"} {
+		m := job.NewMessage(mockMessage{Message: channel})
+		assert.NoError(t, w.broker.push(ctx, w.Redis(), channel, m.Bytes()))
+	}
+
+	// one read returns both entries, the second one is buffered
+	task, err := w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, task))
+	assert.NoError(t, w.Shutdown())
+
+	pending := check.Redis().XPending(ctx, "buffered-b", defaultConsumerGroup).Val()
+	assert.Equal(t, int64(0), pending.Count)
+	assert.Equal(t, int64(2), check.Redis().XLen(ctx, "buffered-b").Val())
+	assert.NoError(t, check.Shutdown())
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("shutdown-timeout"),
+		WithDeliveryMode(List),
+		WithConsumerName("stuck"),
+		WithShutdownTimeout(200*time.Millisecond),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.Queue(&m))
+	// fetched but never run
+	_, err := w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.NoError(t, w.Shutdown())
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the message is left to be recovered
+	check := NewWorker(WithAddr(endpoint))
+	assert.Equal(t, int64(1), check.Redis().LLen(ctx, processingKey("shutdown-timeout", "stuck")).Val())
+	assert.NoError(t, check.Shutdown())
+}
@@ -0,0 +1,146 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultEnqueueFlushInterval = time.Millisecond
+	defaultEnqueueBatchSize     = 100
+)
+
+// pendingPush is a message waiting for the next enqueue batch.
+type pendingPush struct {
+	ctx     context.Context
+	channel string
+	data    []byte
+	done    chan error
+}
+
+// enqueuer coalesces the messages queued within the flush interval, or up
+// to the batch size, into one pipelined write. Callers wait for the write
+// of their batch, so errors are still reported per message.
+type enqueuer struct {
+	w        *Worker
+	interval time.Duration
+	size     int
+
+	mu     sync.Mutex
+	batch  []*pendingPush
+	timer  *time.Timer
+	closed bool
+}
+
+func newEnqueuer(w *Worker) *enqueuer {
+	e := &enqueuer{
+		w:        w,
+		interval: w.opts.enqueueFlushInterval,
+		size:     w.opts.enqueueBatchSize,
+	}
+	if e.interval <= 0 {
+		e.interval = defaultEnqueueFlushInterval
+	}
+	if e.size <= 0 {
+		e.size = defaultEnqueueBatchSize
+	}
+	return e
+}
+
+// push queues a message with the next batch and waits for its write.
+func (e *enqueuer) push(ctx context.Context, channel string, data []byte) error {
+	p := &pendingPush{ctx: ctx, channel: channel, data: data, done: make(chan error, 1)}
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return e.w.publish(ctx, e.w.rdb, channel, data)
+	}
+	e.batch = append(e.batch, p)
+	if len(e.batch) >= e.size {
+		batch := e.take()
+		e.mu.Unlock()
+		e.write(batch)
+	} else {
+		if e.timer == nil {
+			e.timer = time.AfterFunc(e.interval, e.flush)
+		}
+		e.mu.Unlock()
+	}
+
+	return <-p.done
+}
+
+// take returns the pending batch, the caller holds the lock.
+func (e *enqueuer) take() []*pendingPush {
+	batch := e.batch
+	e.batch = nil
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	return batch
+}
+
+func (e *enqueuer) flush() {
+	e.mu.Lock()
+	batch := e.take()
+	e.mu.Unlock()
+	e.write(batch)
+}
+
+// write sends a batch in one pipeline and reports to every message the
+// first error of its commands.
+func (e *enqueuer) write(batch []*pendingPush) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ends := make([]int, len(batch))
+	errs := make([]error, len(batch))
+	cmds, _ := e.w.rdb.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		for i, p := range batch {
+			errs[i] = e.w.publish(p.ctx, pipe, p.channel, p.data)
+			ends[i] = pipe.Len()
+		}
+		return nil
+	})
+
+	start := 0
+	for i, p := range batch {
+		for _, cmd := range cmds[start:min(ends[i], len(cmds))] {
+			if errs[i] == nil {
+				errs[i] = cmd.Err()
+			}
+		}
+		start = ends[i]
+		p.done <- errs[i]
+	}
+}
+
+// close writes the pending messages, the next ones are written right away.
+func (e *enqueuer) close() {
+	e.mu.Lock()
+	e.closed = true
+	batch := e.take()
+	e.mu.Unlock()
+	e.write(batch)
+}
+
+// push stores a message, through the enqueue batches when they are enabled.
+func (w *Worker) push(ctx context.Context, channel string, data []byte) error {
+	var err error
+	if w.enqueuer != nil {
+		err = queueFull(w.enqueuer.push(ctx, channel, data))
+	} else {
+		err = queueFull(w.publish(ctx, w.rdb, channel, data))
+	}
+	if errors.Is(err, ErrQueueFull) && w.opts.blockWhenFull > 0 {
+		return w.waitNotFull(ctx, channel, data)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package redisdb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestEnqueueBatch(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("batched"),
+		WithDeliveryMode(List),
+		WithEnqueueFlushInterval(10*time.Millisecond),
+		WithEnqueueBatchSize(10),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := job.NewMessage(mockMessage{Message: "foo"})
+			assert.NoError(t, w.Queue(&m))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(25), w.Redis().LLen(ctx, "batched").Val())
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestEnqueueBatchErrors(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("default"),
+		WithDeliveryMode(Sidekiq),
+		WithEnqueueFlushInterval(time.Hour),
+		WithEnqueueBatchSize(2),
+	)
+
+	// the batch is written once full, the job without class fails alone
+	errs := make(chan error, 2)
+	for _, class := range []string{"", "HardJob"} {
+		go func() {
+			ctx := ContextWithMetadata(ctx, Metadata{Headers: map[string]string{SidekiqClassHeader: class}})
+			m := job.NewMessage(mockMessage{Message: "[]"})
+			errs <- w.QueueContext(ctx, &m)
+		}()
+	}
+	var failed int
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			assert.ErrorIs(t, err, ErrNoSidekiqClass)
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, int64(1), w.Redis().LLen(ctx, "queue:default").Val())
+
+	// pending messages are written on shutdown
+	go func() {
+		ctx := ContextWithMetadata(ctx, Metadata{Headers: map[string]string{SidekiqClassHeader: "HardJob"}})
+		m := job.NewMessage(mockMessage{Message: "[]"})
+		errs <- w.QueueContext(ctx, &m)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, w.Shutdown())
+	assert.NoError(t, <-errs)
+}
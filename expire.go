@@ -0,0 +1,45 @@
+package redisdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// expiredMaxLen caps the expired list of a channel, the oldest messages
+// are dropped first.
+const expiredMaxLen = 1000
+
+// expiredKey is the list holding the last jobs of channel that expired
+// before they ran, see WithJobTTL.
+func expiredKey(channel string) string {
+	return channel + ":expired"
+}
+
+// expired reports whether the job must not run anymore at now.
+func (e envelope) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// expire settles a delivery whose job expired before it ran, keeping its
+// message in the expired list of the channel.
+func (w *Worker) expire(d *delivery, env envelope) {
+	ctx := context.Background()
+	_, err := w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, expiredKey(d.channel), d.data)
+		pipe.LTrim(ctx, expiredKey(d.channel), 0, expiredMaxLen-1)
+		return nil
+	})
+	if err == nil {
+		err = w.broker.ack(ctx, d)
+	}
+	if err != nil {
+		w.log.Error("failed to expire message", "channel", d.channel, "job_id", env.ID, "error", err)
+		return
+	}
+	w.dropJSONPayload(ctx, env)
+	w.metrics.recordSettled(ctx, d.channel, true)
+	w.log.Warn("job expired before it ran", "channel", d.channel, "job_id", env.ID, "expires_at", env.ExpiresAt)
+	w.finishGroup(ctx, env.Group, 1, 1)
+}
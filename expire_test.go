@@ -0,0 +1,58 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestJobTTL(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "job-ttl"
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+		WithJobTTL(50*time.Millisecond),
+	)
+	defer w.Shutdown()
+	producer := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+	)
+	defer producer.Shutdown()
+
+	late := job.NewMessage(mockMessage{Message: "late"})
+	expiredID, err := w.QueueWithID(ctx, &late)
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	onTime := job.NewMessage(mockMessage{Message: "on time"})
+	_, err = producer.QueueWithID(ctx, &onTime)
+	require.NoError(t, err)
+
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "on time", string(task.Payload()))
+	require.NoError(t, w.Run(ctx, task))
+
+	expired, err := w.Redis().LRange(ctx, expiredKey(channel), 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	_, env, err := w.decode([]byte(expired[0]))
+	require.NoError(t, err)
+	assert.Equal(t, expiredID, env.ID)
+	assert.False(t, env.ExpiresAt.IsZero())
+
+	n, err := w.Redis().LLen(ctx, processingKey(channel, w.opts.consumerName)).Result()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,80 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestStreamGroupLost(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("failover"),
+		WithDeliveryMode(Stream),
+	)
+	rdb := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer rdb.Close()
+
+	m := job.NewMessage(mockMessage{Message: "before"})
+	require.NoError(t, w.Queue(&m))
+	msg, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, msg))
+
+	// a replica promoted without the consumer group
+	require.NoError(t, rdb.XGroupDestroy(ctx, "failover", defaultConsumerGroup).Err())
+	m = job.NewMessage(mockMessage{Message: "after"})
+	require.NoError(t, w.Queue(&m))
+
+	// the group is created again from where the worker was
+	msg, err = w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "after", string(msg.Payload()))
+	assert.NoError(t, w.Run(ctx, msg))
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestPubSubReconnect(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	received := make(chan string, 10)
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("resubscribe"),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			received <- string(m.Payload())
+			return nil
+		}),
+	)
+	rdb := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer rdb.Close()
+
+	// the subscription is dropped, as when its server fails over
+	require.NoError(t, rdb.ClientKillByFilter(ctx, "TYPE", "pubsub").Err())
+
+	assert.Eventually(t, func() bool {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		require.NoError(t, w.Queue(&m))
+		msg, err := w.Fetch(ctx, 100*time.Millisecond)
+		if err != nil {
+			return false
+		}
+		assert.NoError(t, w.Run(ctx, msg))
+		return true
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, "foo", <-received)
+	assert.NoError(t, w.Shutdown())
+}
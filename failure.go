@@ -0,0 +1,146 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// failureTTL is how long the failure details of a job are kept after its
+// last failed run.
+const failureTTL = 7 * 24 * time.Hour
+
+// failureKey is the hash holding the failed runs of the job id.
+func failureKey(id string) string {
+	return "redisdb:failure:" + id
+}
+
+// FailedRun describes a failed run of a job.
+type FailedRun struct {
+	Attempt  int           `json:"attempt"`
+	Error    string        `json:"error"`
+	Duration time.Duration `json:"duration"`
+	// Worker is the consumer name of the worker that ran the job.
+	Worker string    `json:"worker"`
+	At     time.Time `json:"at"`
+}
+
+// JobFailure gathers the failed runs of a job, see Inspector.Failure.
+type JobFailure struct {
+	ID      string `json:"id"`
+	Channel string `json:"channel"`
+	// LastError is the error of the last failed run.
+	LastError string `json:"last_error"`
+	// Runs are the failed runs, oldest first.
+	Runs []FailedRun `json:"runs"`
+	// DeadLetteredAt is when the job was moved to the dead letter queue,
+	// zero when it was not.
+	DeadLetteredAt time.Time `json:"dead_lettered_at,omitempty"`
+}
+
+// recordFailure stores the details of a failed run of a job in its failure
+// hash, for Inspector.Failure. Jobs without ID are not recorded.
+func (w *Worker) recordFailure(ctx context.Context, info JobInfo, runErr error) {
+	if info.ID == "" {
+		return
+	}
+	key := w.opts.key(failureKey(info.ID))
+	prefix := "run:" + strconv.Itoa(info.Attempt) + ":"
+	_, err := w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"channel", info.Channel,
+			"last_error", runErr.Error(),
+			prefix+"error", runErr.Error(),
+			prefix+"duration", info.Duration.Microseconds(),
+			prefix+"worker", w.opts.consumerName,
+			prefix+"at", time.Now().UnixMilli(),
+		)
+		pipe.Expire(ctx, key, failureTTL)
+		return nil
+	})
+	if err != nil {
+		w.log.Error("failed to record job failure", "job_id", info.ID, "error", err)
+	}
+}
+
+// recordDeadLettered marks the failure hash of a dead-lettered job.
+func (w *Worker) recordDeadLettered(ctx context.Context, id string) {
+	if id == "" {
+		return
+	}
+	err := w.rdb.HSet(ctx, w.opts.key(failureKey(id)), "dead_lettered_at", time.Now().UnixMilli()).Err()
+	if err != nil {
+		w.log.Error("failed to record job failure", "job_id", id, "error", err)
+	}
+}
+
+// Failure returns the failed runs of the job with the given ID, recorded
+// by the workers for 7 days after the last one. It returns ErrJobNotFound
+// when the job has no failed run.
+func (i *Inspector) Failure(ctx context.Context, id string) (JobFailure, error) {
+	fields, err := i.w.rdb.HGetAll(ctx, i.w.opts.key(failureKey(id))).Result()
+	if err != nil {
+		return JobFailure{}, err
+	}
+	if len(fields) == 0 {
+		return JobFailure{}, ErrJobNotFound
+	}
+
+	f := JobFailure{ID: id, Channel: fields["channel"], LastError: fields["last_error"]}
+	if ms, err := strconv.ParseInt(fields["dead_lettered_at"], 10, 64); err == nil {
+		f.DeadLetteredAt = time.UnixMilli(ms)
+	}
+	runs := make(map[int]*FailedRun)
+	for name, value := range fields {
+		// run:<attempt>:<field>
+		rest, ok := strings.CutPrefix(name, "run:")
+		if !ok {
+			continue
+		}
+		n, field, ok := strings.Cut(rest, ":")
+		attempt, err := strconv.Atoi(n)
+		if !ok || err != nil {
+			continue
+		}
+		r := runs[attempt]
+		if r == nil {
+			r = &FailedRun{Attempt: attempt}
+			runs[attempt] = r
+		}
+		switch field {
+		case "error":
+			r.Error = value
+		case "duration":
+			us, _ := strconv.ParseInt(value, 10, 64)
+			r.Duration = time.Duration(us) * time.Microsecond
+		case "worker":
+			r.Worker = value
+		case "at":
+			ms, _ := strconv.ParseInt(value, 10, 64)
+			r.At = time.UnixMilli(ms)
+		}
+	}
+	for _, r := range runs {
+		f.Runs = append(f.Runs, *r)
+	}
+	sort.Slice(f.Runs, func(a, b int) bool { return f.Runs[a].Attempt < f.Runs[b].Attempt })
+	return f, nil
+}
+
+// lastError returns the error of the last failed run of the job id, empty
+// when it is not known.
+func (i *Inspector) lastError(ctx context.Context, id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	msg, err := i.w.rdb.HGet(ctx, i.w.opts.key(failureKey(id)), "last_error").Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return msg, err
+}
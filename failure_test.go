@@ -0,0 +1,68 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestFailure(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "failure"
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+		WithConsumerName("worker-1"),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			md, _ := MetadataFromContext(ctx)
+			if md.Attempt == 1 {
+				return errors.New("timeout")
+			}
+			return errors.New("connection refused")
+		}),
+	)
+	defer w.Shutdown()
+	i := &Inspector{w: w}
+
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{
+		RetryCount: job.Int64(1),
+		RetryDelay: job.Time(time.Millisecond),
+	})
+	id, err := w.QueueWithID(ctx, &m)
+	require.NoError(t, err)
+
+	_, err = i.Failure(ctx, id)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	// the job fails both attempts and is dead-lettered
+	_, err = w.DrainOnce(ctx)
+	assert.Error(t, err)
+
+	f, err := i.Failure(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, channel, f.Channel)
+	assert.Equal(t, "connection refused", f.LastError)
+	assert.False(t, f.DeadLetteredAt.IsZero())
+	require.Len(t, f.Runs, 2)
+	assert.Equal(t, 1, f.Runs[0].Attempt)
+	assert.Equal(t, "timeout", f.Runs[0].Error)
+	assert.Equal(t, "worker-1", f.Runs[0].Worker)
+	assert.WithinDuration(t, time.Now(), f.Runs[1].At, time.Minute)
+	assert.Greater(t, w.Redis().TTL(ctx, failureKey(id)).Val(), time.Duration(0))
+
+	j, err := i.Job(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, JobDead, j.State)
+	assert.Equal(t, "connection refused", j.LastError)
+}
@@ -0,0 +1,77 @@
+package redisdb
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ServerFlavor is the server implementing the Redis protocol, see
+// WithServerFlavor.
+type ServerFlavor int
+
+const (
+	// FlavorRedis is Redis, or a server compatible with its latest
+	// releases like Valkey. It is the default.
+	FlavorRedis ServerFlavor = iota
+	// FlavorDragonfly is Dragonfly, which has no functions nor keyspace
+	// notifications other than expirations.
+	FlavorDragonfly
+	// FlavorKeyDB is KeyDB, compatible with Redis 6.
+	FlavorKeyDB
+)
+
+func (f ServerFlavor) String() string {
+	switch f {
+	case FlavorRedis:
+		return "redis"
+	case FlavorDragonfly:
+		return "dragonfly"
+	case FlavorKeyDB:
+		return "keydb"
+	default:
+		return "unknown"
+	}
+}
+
+// functions reports whether the server can run the queue scripts as
+// Redis Functions.
+func (f ServerFlavor) functions() bool {
+	return f == FlavorRedis
+}
+
+// keyspaceEvents reports whether the server publishes the keyspace
+// notifications of lists and sorted sets.
+func (f ServerFlavor) keyspaceEvents() bool {
+	return f != FlavorDragonfly
+}
+
+// staleEntries returns up to count entries of the stream pending in the
+// group for longer than the consumer TTL. Servers other than Redis may not
+// filter XPENDING on the idle time, it is then filtered here.
+func staleEntries(ctx context.Context, rdb redis.Cmdable, flavor ServerFlavor, stream, group string, count int64) ([]redis.XPendingExt, error) {
+	args := &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Idle:   consumerTTL,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}
+	if flavor == FlavorRedis {
+		return rdb.XPendingExt(ctx, args).Result()
+	}
+
+	args.Idle = 0
+	pending, err := rdb.XPendingExt(ctx, args).Result()
+	if err != nil {
+		return nil, err
+	}
+	stale := pending[:0]
+	for _, p := range pending {
+		if p.Idle >= consumerTTL {
+			stale = append(stale, p)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,86 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestServerFlavors(t *testing.T) {
+	ctx := context.Background()
+	servers := []struct {
+		flavor ServerFlavor
+		image  string
+		ready  string
+	}{
+		{FlavorRedis, "redis:7", "Ready to accept connections"},
+		{FlavorDragonfly, "docker.dragonflydb.io/dragonflydb/dragonfly:v1.24.0", "listening on port"},
+		{FlavorKeyDB, "eqalpha/keydb:x86_64_v6.3.4", "Ready to accept connections"},
+	}
+
+	for _, s := range servers {
+		t.Run(s.flavor.String(), func(t *testing.T) {
+			redisC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+				ContainerRequest: testcontainers.ContainerRequest{
+					Image:        s.image,
+					ExposedPorts: []string{"6379/tcp"},
+					WaitingFor:   wait.ForLog(s.ready),
+				},
+				Started: true,
+			})
+			defer testcontainers.CleanupContainer(t, redisC)
+			require.NoError(t, err)
+			endpoint, err := redisC.Endpoint(ctx, "")
+			require.NoError(t, err)
+
+			for _, mode := range []DeliveryMode{List, Stream} {
+				t.Run(mode.String(), func(t *testing.T) {
+					channel := "flavor-" + mode.String()
+					w := NewWorker(
+						WithAddr(endpoint),
+						WithChannel(channel),
+						WithDeliveryMode(mode),
+						WithDeliveryGuarantee(AtLeastOnce),
+						WithServerFlavor(s.flavor),
+					)
+					defer w.Shutdown()
+					assert.Equal(t, s.flavor.String(), w.Topology().Flavor)
+					if s.flavor != FlavorRedis {
+						assert.Nil(t, w.functions)
+					}
+
+					for _, v := range []string{"a", "b", "c"} {
+						m := job.NewMessage(mockMessage{Message: v})
+						require.NoError(t, w.Queue(&m))
+					}
+					for range 3 {
+						task, err := w.Fetch(ctx, time.Second)
+						require.NoError(t, err)
+						require.NoError(t, w.Run(ctx, task))
+					}
+
+					// stale entries are filtered by the worker
+					if mode == Stream {
+						m := job.NewMessage(mockMessage{Message: "held"})
+						require.NoError(t, w.Queue(&m))
+						task, err := w.Fetch(ctx, time.Second)
+						require.NoError(t, err)
+						stale, err := staleEntries(ctx, w.Redis(), s.flavor, channel, w.opts.consumerGroup, 10)
+						require.NoError(t, err)
+						assert.Empty(t, stale)
+						require.NoError(t, w.Run(ctx, task))
+					}
+
+					_, err := w.Degradations(ctx)
+					assert.NoError(t, err)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package redisdb
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// functionsMinVersion is the first major version of Redis with Functions.
+const functionsMinVersion = 7
+
+// queueScript is a script of the queue operations. It runs as a function
+// of the library of the package on Redis 7 and later, and with EVALSHA on
+// older servers. The scripts of the asynq and Sidekiq layouts touch keys
+// they are not passed, which functions forbid, and stay plain scripts.
+type queueScript struct {
+	*redis.Script
+	name string
+	src  string
+}
+
+// queueScripts are the scripts loaded in the function library.
+var queueScripts []*queueScript
+
+func newQueueScript(name, src string) *queueScript {
+	s := &queueScript{Script: redis.NewScript(src), name: name, src: src}
+	queueScripts = append(queueScripts, s)
+	return s
+}
+
+// run calls the script as a function of lib, or with EVALSHA when lib is
+// nil.
+func (s *queueScript) run(ctx context.Context, rdb redis.Cmdable, lib *functionLibrary, keys []string, args ...interface{}) *redis.Cmd {
+	if lib == nil {
+		return s.Run(ctx, rdb, keys, args...)
+	}
+	cmd := rdb.FCall(ctx, lib.function(s), keys, args...)
+	if err := cmd.Err(); err != nil && strings.Contains(err.Error(), "Function not found") {
+		// the server restarted without persistence or was flushed
+		if err := lib.load(ctx); err != nil {
+			return s.Run(ctx, rdb, keys, args...)
+		}
+		cmd = rdb.FCall(ctx, lib.function(s), keys, args...)
+	}
+	return cmd
+}
+
+// functionLibrary is the library holding the queue scripts as Redis
+// Functions. It is named after the hash of its code, so workers of
+// different versions of the package sharing a server do not replace the
+// functions of each other.
+type functionLibrary struct {
+	rdb  redis.Cmdable
+	name string
+	code string
+	mu   sync.Mutex
+}
+
+var (
+	libraryOnce sync.Once
+	libraryName string
+	libraryCode string
+)
+
+// buildLibrary writes the code of the function library.
+func buildLibrary() (string, string) {
+	libraryOnce.Do(func() {
+		scripts := append([]*queueScript(nil), queueScripts...)
+		sort.Slice(scripts, func(i, j int) bool { return scripts[i].name < scripts[j].name })
+
+		hash := sha1.New()
+		for _, s := range scripts {
+			hash.Write([]byte(s.name + "\n" + s.src))
+		}
+		libraryName = "redisdb_" + hex.EncodeToString(hash.Sum(nil)[:6])
+
+		var code strings.Builder
+		fmt.Fprintf(&code, "#!lua name=%s\n", libraryName)
+		for _, s := range scripts {
+			fmt.Fprintf(&code, "redis.register_function('%s_%s', function(KEYS, ARGV)\n%s\nend)\n", libraryName, s.name, s.src)
+		}
+		libraryCode = code.String()
+	})
+	return libraryName, libraryCode
+}
+
+func (l *functionLibrary) function(s *queueScript) string {
+	return l.name + "_" + s.name
+}
+
+// load loads the library on the server, on every master of a cluster.
+func (l *functionLibrary) load(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	load := func(ctx context.Context, rdb redis.Cmdable) error {
+		err := rdb.FunctionLoad(ctx, l.code).Err()
+		if err != nil && strings.Contains(err.Error(), "already exists") {
+			return nil
+		}
+		return err
+	}
+	if c, ok := l.rdb.(*redis.ClusterClient); ok {
+		return c.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return load(ctx, client)
+		})
+	}
+	return load(ctx, l.rdb)
+}
+
+// loadFunctions loads the queue scripts as functions when the server runs
+// Redis 7 or later. It returns nil when the scripts must be run with
+// EVALSHA instead.
+func (w *Worker) loadFunctions(ctx context.Context) *functionLibrary {
+	if !w.opts.flavor.functions() {
+		return nil
+	}
+	info, err := w.rdb.Info(ctx, "server").Result()
+	if err != nil || serverMajorVersion(info) < functionsMinVersion {
+		return nil
+	}
+
+	name, code := buildLibrary()
+	lib := &functionLibrary{rdb: w.rdb, name: name, code: code}
+	if err := lib.load(ctx); err != nil {
+		// managed services may forbid FUNCTION LOAD
+		w.log.Warn("failed to load redis functions, using scripts", "error", err)
+		return nil
+	}
+	return lib
+}
+
+// serverMajorVersion reads the major version of Redis from the server
+// section of INFO, 0 when it is missing.
+func serverMajorVersion(info string) int {
+	for _, line := range strings.Split(info, "\n") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:")
+		if !ok {
+			continue
+		}
+		major, _ := strconv.Atoi(strings.SplitN(v, ".", 2)[0])
+		return major
+	}
+	return 0
+}
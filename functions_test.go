@@ -0,0 +1,51 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestServerMajorVersion(t *testing.T) {
+	assert.Equal(t, 7, serverMajorVersion("# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n"))
+	assert.Equal(t, 6, serverMajorVersion("redis_version:6.2.14\r\n"))
+	assert.Equal(t, 0, serverMajorVersion(""))
+}
+
+func TestFunctions(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisImage(ctx, t, "redis:7")
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("functions"),
+		WithDeliveryMode(List),
+	)
+	defer w.Shutdown()
+	require.NotNil(t, w.functions)
+
+	libs, err := w.Redis().FunctionList(ctx, redis.FunctionListQuery{}).Result()
+	require.NoError(t, err)
+	require.Len(t, libs, 1)
+	assert.Equal(t, w.functions.name, libs[0].Name)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	require.NoError(t, w.Queue(&m))
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Run(ctx, task))
+
+	// the functions are loaded again once flushed
+	require.NoError(t, w.Redis().FunctionFlush(ctx).Err())
+	require.NoError(t, w.Queue(&m))
+	task, err = w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, task))
+}
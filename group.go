@@ -0,0 +1,142 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// groupTTL bounds how long the state of an unfinished group is kept.
+const groupTTL = 7 * 24 * time.Hour
+
+func groupKey(id string) string {
+	return "redisdb:group:" + id
+}
+
+// groupDoneScript counts ARGV[1] more finished jobs, ARGV[2] of them
+// failed, in the group KEYS[1]. When none is left it deletes the group and
+// returns its size, failed jobs, and the channel and message of its
+// completion job, the worker finishing the last job queues it.
+var groupDoneScript = newQueueScript("group_done", `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return false
+end
+local remaining = redis.call('HINCRBY', KEYS[1], 'remaining', -tonumber(ARGV[1]))
+local failed = redis.call('HINCRBY', KEYS[1], 'failed', tonumber(ARGV[2]))
+if remaining > 0 then
+  return false
+end
+local g = redis.call('HMGET', KEYS[1], 'size', 'channel', 'completion')
+redis.call('DEL', KEYS[1])
+return {tonumber(g[1]), failed, g[2] or '', g[3] or ''}
+`)
+
+// Group is a set of related jobs queued together, whose completion is
+// tracked in Redis, see QueueGroup.
+type Group struct {
+	// ID identifies the group, a ULID is assigned when it is empty.
+	ID   string
+	Jobs []core.TaskMessage
+	// Completion is queued once every job of the group finished, whether
+	// it succeeded or failed for good. It is optional.
+	Completion core.TaskMessage
+}
+
+// GroupInfo describes a group whose jobs all finished.
+type GroupInfo struct {
+	ID string
+	// Size is the number of jobs of the group.
+	Size int64
+	// Failed counts the jobs that did not succeed: those that failed after
+	// all their attempts, and those dropped without running because they
+	// expired, were cancelled or could not be loaded.
+	Failed int64
+}
+
+// QueueGroup queues the jobs of g and returns the ID of the group. Once all
+// of them finished, the worker running the last one queues the completion
+// job of the group and calls the OnGroupComplete hook. Jobs that expire,
+// are cancelled or cannot be loaded finish the group as failed. Handlers read the
+// group of their job from its Metadata. Groups whose jobs do not all
+// finish within 7 days are forgotten.
+func (w *Worker) QueueGroup(ctx context.Context, g Group) (string, error) {
+	if len(g.Jobs) == 0 {
+		return "", errors.New("redisdb: group has no jobs")
+	}
+	id := g.ID
+	if id == "" {
+		id = ulid.Make().String()
+	}
+	key := w.opts.key(groupKey(id))
+
+	values := []interface{}{"size", len(g.Jobs), "remaining", len(g.Jobs), "failed", 0}
+	if g.Completion != nil {
+		env := w.newEnvelope(ctx, ulid.Make().String())
+		channel, m, err := w.route(g.Completion, env)
+		if err != nil {
+			return "", err
+		}
+		data, err := w.encode(m, env)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, "channel", channel, "completion", data)
+	}
+	// the group is stored first, so jobs finishing right away find it
+	_, err := w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, values...)
+		pipe.Expire(ctx, key, groupTTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for i, task := range g.Jobs {
+		env := w.newEnvelope(ctx, ulid.Make().String())
+		env.Group = id
+		if err := w.send(ctx, task, env); err != nil {
+			// the jobs not queued will not finish
+			w.finishGroup(ctx, id, int64(len(g.Jobs)-i), 0)
+			return id, fmt.Errorf("redisdb: queued %d of %d jobs of group %s: %w", i, len(g.Jobs), id, err)
+		}
+	}
+	return id, nil
+}
+
+// finishGroup records that n jobs of the group id finished, failed of
+// them for good, and completes the group when they were the last ones. It
+// does nothing when id is empty, for jobs queued outside of a group.
+func (w *Worker) finishGroup(ctx context.Context, id string, n, failed int64) {
+	if id == "" {
+		return
+	}
+	res, err := groupDoneScript.run(ctx, w.rdb, w.functions, []string{w.opts.key(groupKey(id))}, n, failed).Slice()
+	if errors.Is(err, redis.Nil) {
+		return
+	}
+	if err != nil || len(res) != 4 {
+		w.log.Error("failed to update job group", "group", id, "error", err)
+		return
+	}
+
+	info := GroupInfo{ID: id}
+	info.Size, _ = res[0].(int64)
+	info.Failed, _ = res[1].(int64)
+	channel, _ := res[2].(string)
+	completion, _ := res[3].(string)
+	if completion != "" {
+		// the completion job is queued now, not when the group was
+		if err := w.push(ctx, channel, w.restamp([]byte(completion))); err != nil {
+			w.log.Error("failed to queue group completion job", "group", id, "channel", channel, "error", err)
+		} else {
+			w.metrics.recordPublished(ctx, channel)
+		}
+	}
+	w.opts.hooks.groupComplete(ctx, info)
+}
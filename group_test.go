@@ -0,0 +1,115 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestQueueGroup(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var (
+		groups    []string
+		completed []GroupInfo
+	)
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("group"),
+		WithDeliveryMode(List),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			md, _ := MetadataFromContext(ctx)
+			groups = append(groups, md.Group)
+			if string(m.Payload()) == "bad" {
+				return errors.New("failed")
+			}
+			return nil
+		}),
+		WithHooks(Hooks{
+			OnGroupComplete: func(ctx context.Context, group GroupInfo) {
+				completed = append(completed, group)
+			},
+		}),
+	)
+	defer w.Shutdown()
+
+	msg := func(s string) core.TaskMessage {
+		m := job.NewMessage(mockMessage{Message: s})
+		return &m
+	}
+	id, err := w.QueueGroup(ctx, Group{
+		ID:         "batch-1",
+		Jobs:       []core.TaskMessage{msg("a"), msg("bad"), msg("c")},
+		Completion: msg("done"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "batch-1", id)
+
+	for range 3 {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		_ = w.Run(ctx, task)
+	}
+	assert.Equal(t, []string{"batch-1", "batch-1", "batch-1"}, groups)
+	assert.Equal(t, []GroupInfo{{ID: "batch-1", Size: 3, Failed: 1}}, completed)
+
+	// the completion job was queued by the last job
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(task.Payload()))
+	require.NoError(t, w.Run(ctx, task))
+	assert.Equal(t, "", groups[3])
+	assert.Len(t, completed, 1)
+	assert.Zero(t, w.Redis().Exists(ctx, groupKey("batch-1")).Val())
+}
+
+func TestQueueGroupExpired(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var completed []GroupInfo
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("group-expired"),
+		WithDeliveryMode(List),
+		WithJobTTL(200*time.Millisecond),
+		WithHooks(Hooks{
+			OnGroupComplete: func(ctx context.Context, group GroupInfo) {
+				completed = append(completed, group)
+			},
+		}),
+	)
+	defer w.Shutdown()
+
+	msg := func(s string) core.TaskMessage {
+		m := job.NewMessage(mockMessage{Message: s})
+		return &m
+	}
+	_, err := w.QueueGroup(ctx, Group{
+		ID:         "batch-2",
+		Jobs:       []core.TaskMessage{msg("a"), msg("b")},
+		Completion: msg("done"),
+	})
+	require.NoError(t, err)
+	time.Sleep(300 * time.Millisecond)
+
+	// the expired jobs finish the group, whose completion job is queued
+	// with a TTL of its own
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(task.Payload()))
+	assert.Equal(t, []GroupInfo{{ID: "batch-2", Size: 2, Failed: 2}}, completed)
+	v, ok := w.deliveries.Load(task)
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(200*time.Millisecond), v.(*delivery).env.ExpiresAt, 100*time.Millisecond)
+}
@@ -0,0 +1,51 @@
+package redisdb
+
+// DeliveryGuarantee selects the delivery semantics of a worker, see
+// WithDeliveryGuarantee.
+type DeliveryGuarantee int
+
+const (
+	// AtLeastOnce runs every job until it succeeds or fails all its
+	// attempts, even when its consumer crashes: jobs are acknowledged once
+	// they succeed and the jobs held by dead consumers are reclaimed by the
+	// live ones. Jobs may run more than once.
+	AtLeastOnce DeliveryGuarantee = iota + 1
+	// AtMostOnce never runs a job twice: jobs are acknowledged when they
+	// are received, and the jobs that fail or whose consumer crashes are
+	// lost.
+	AtMostOnce
+)
+
+func (g DeliveryGuarantee) String() string {
+	switch g {
+	case AtLeastOnce:
+		return "at-least-once"
+	case AtMostOnce:
+		return "at-most-once"
+	default:
+		return ""
+	}
+}
+
+// withGuarantee sets the delivery mode and the ack policy matching the
+// delivery guarantee. At least once needs a mode keeping the messages, so
+// the PubSub mode is replaced by the Stream one.
+func (o *options) withGuarantee() {
+	switch o.guarantee {
+	case AtLeastOnce:
+		if o.mode == PubSub {
+			o.mode = Stream
+		}
+		if o.ackPolicy == AckBeforeRun {
+			o.ackPolicy = AckAfterSuccess
+		}
+	case AtMostOnce:
+		o.ackPolicy = AckBeforeRun
+	}
+}
+
+// reclaims reports whether the messages of dead consumers are reclaimed
+// while the worker runs, rather than when the next worker starts.
+func (o *options) reclaims() bool {
+	return o.guarantee == AtLeastOnce
+}
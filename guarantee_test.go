@@ -0,0 +1,117 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestDeliveryGuaranteeOptions(t *testing.T) {
+	opts := newOptions(WithDeliveryGuarantee(AtLeastOnce), WithAckPolicy(AckBeforeRun))
+	assert.Equal(t, Stream, opts.mode)
+	assert.Equal(t, AckAfterSuccess, opts.ackPolicy)
+
+	opts = newOptions(WithDeliveryGuarantee(AtLeastOnce), WithDeliveryMode(List))
+	assert.Equal(t, List, opts.mode)
+
+	opts = newOptions(WithDeliveryGuarantee(AtMostOnce), WithDeliveryMode(List))
+	assert.Equal(t, AckBeforeRun, opts.ackPolicy)
+	assert.Equal(t, "at-most-once", newTopology(opts).Guarantee)
+}
+
+func TestAtLeastOnceCrash(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	crashed := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("at-least-once"),
+		WithConsumerName("crashed"),
+		WithDeliveryGuarantee(AtLeastOnce),
+	)
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	require.NoError(t, crashed.Queue(&m))
+	// the consumer takes the job and dies before running it
+	_, err := crashed.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+
+	var runs int
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("at-least-once"),
+		WithConsumerName("alive"),
+		WithDeliveryGuarantee(AtLeastOnce),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			runs++
+			return nil
+		}),
+	)
+	rdb := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer rdb.Close()
+	_, err = w.Fetch(ctx, 100*time.Millisecond)
+	assert.Error(t, err)
+
+	// once its entry is idle for the consumer TTL, a live consumer claims it
+	id := rdb.XRange(ctx, "at-least-once", "-", "+").Val()[0].ID
+	require.NoError(t, rdb.Do(ctx, "XCLAIM", "at-least-once", defaultConsumerGroup, "crashed",
+		0, id, "IDLE", (2*consumerTTL).Milliseconds(), "JUSTID").Err())
+	require.NoError(t, w.broker.(*streamBroker).reclaim(ctx))
+	msg, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, msg))
+	assert.Equal(t, 1, runs)
+	assert.Zero(t, rdb.XPending(ctx, "at-least-once", defaultConsumerGroup).Val().Count)
+	assert.NoError(t, w.Shutdown())
+	assert.NoError(t, crashed.Shutdown())
+}
+
+func TestAtMostOnceCrash(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var runs int
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("at-most-once"),
+		WithDeliveryMode(List),
+		WithConsumerName("crashing"),
+		WithDeliveryGuarantee(AtMostOnce),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			runs++
+			return errors.New("crashed")
+		}),
+	)
+	rdb := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer rdb.Close()
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{RetryCount: job.Int64(3)})
+	require.NoError(t, w.Queue(&m))
+
+	msg, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Error(t, w.Run(ctx, msg))
+	assert.NoError(t, w.Shutdown())
+
+	// the job was acknowledged when received, nothing recovers it
+	assert.NoError(t, rdb.ZAdd(ctx, consumersKey("at-most-once"), redis.Z{Score: 1, Member: "crashing"}).Err())
+	next := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("at-most-once"),
+		WithDeliveryMode(List),
+		WithDeliveryGuarantee(AtMostOnce),
+	)
+	_, err = next.Fetch(ctx, 100*time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, 1, runs)
+	assert.Zero(t, rdb.XLen(ctx, deadKey("at-most-once")).Val())
+	assert.NoError(t, next.Shutdown())
+}
@@ -0,0 +1,74 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// defaultStallTimeout is how long a consumer may go without fetching or
+// finishing a job before Healthy reports it stalled.
+const defaultStallTimeout = 5 * time.Minute
+
+var (
+	// ErrWorkerStopped is returned by the probes of a shut down worker.
+	ErrWorkerStopped = errors.New("redisdb: worker is shut down")
+	// ErrWorkerStalled is returned by Healthy when the consumer loop made
+	// no progress within the stall timeout, see WithHealthCheck.
+	ErrWorkerStalled = errors.New("redisdb: consumer loop stalled")
+	// ErrLagTooHigh is returned by Healthy when a pending job waits longer
+	// than the lag set by WithHealthCheck.
+	ErrLagTooHigh = errors.New("redisdb: consumer lag too high")
+)
+
+// markActive records the progress of the consumer loop.
+func (w *Worker) markActive() {
+	atomic.StoreInt64(&w.lastActive, time.Now().UnixNano())
+}
+
+// Ready reports whether the worker can take jobs: it is running and Redis
+// answers. It is meant for readiness probes.
+func (w *Worker) Ready(ctx context.Context) error {
+	if atomic.LoadInt32(&w.stopFlag) == 1 {
+		return ErrWorkerStopped
+	}
+	return w.rdb.Ping(ctx).Err()
+}
+
+// Healthy reports whether the worker is ready and its consumer loop makes
+// progress: a job was fetched or finished within the stall timeout, and no
+// pending job waits longer than the maximum lag, see WithHealthCheck. It
+// is meant for liveness probes, so a wedged consumer gets restarted. The
+// stall check starts with the first fetch, workers only queueing jobs are
+// not checked.
+func (w *Worker) Healthy(ctx context.Context) error {
+	if err := w.Ready(ctx); err != nil {
+		return err
+	}
+
+	if last := atomic.LoadInt64(&w.lastActive); last > 0 {
+		if idle := time.Since(time.Unix(0, last)); idle > w.opts.stallTimeout {
+			return fmt.Errorf("%w: no progress for %s", ErrWorkerStalled, idle.Round(time.Second))
+		}
+	}
+
+	if w.opts.maxLag <= 0 {
+		return nil
+	}
+	i := &Inspector{w: w}
+	for _, channel := range w.opts.channels {
+		pending, err := i.listPending(ctx, channel, 1)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 || pending[0].EnqueuedAt.IsZero() {
+			continue
+		}
+		if lag := time.Since(pending[0].EnqueuedAt); lag > w.opts.maxLag {
+			return fmt.Errorf("%w: %s waits for %s", ErrLagTooHigh, channel, lag.Round(time.Second))
+		}
+	}
+	return nil
+}
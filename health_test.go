@@ -0,0 +1,46 @@
+package redisdb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestHealthy(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("health"),
+		WithDeliveryMode(List),
+		WithHealthCheck(time.Minute, 500*time.Millisecond),
+	)
+
+	assert.NoError(t, w.Ready(ctx))
+	assert.NoError(t, w.Healthy(ctx))
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	require.NoError(t, w.Queue(&m))
+	time.Sleep(time.Second)
+	assert.ErrorIs(t, w.Healthy(ctx), ErrLagTooHigh)
+
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Run(ctx, task))
+	assert.NoError(t, w.Healthy(ctx))
+
+	atomic.StoreInt64(&w.lastActive, time.Now().Add(-2*time.Minute).UnixNano())
+	assert.ErrorIs(t, w.Healthy(ctx), ErrWorkerStalled)
+
+	require.NoError(t, w.Shutdown())
+	assert.ErrorIs(t, w.Ready(ctx), ErrWorkerStopped)
+	assert.ErrorIs(t, w.Healthy(ctx), ErrWorkerStopped)
+}
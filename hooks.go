@@ -0,0 +1,76 @@
+package redisdb
+
+import (
+	"context"
+	"time"
+)
+
+// JobInfo describes the job a hook is called for.
+type JobInfo struct {
+	// ID is the ID assigned when the job was queued, see QueueWithID.
+	ID      string
+	Channel string
+	// Attempt counts the runs of the job by this worker, from 1.
+	Attempt int
+	Payload []byte
+	// Duration is how long the run func took, zero when the job starts.
+	Duration time.Duration
+}
+
+// Hooks are callbacks called by Run on the lifecycle events of jobs. They
+// run synchronously in the worker goroutine, so they must be quick. Nil
+// hooks are skipped.
+type Hooks struct {
+	// OnJobStart is called before the run func.
+	OnJobStart func(ctx context.Context, job JobInfo)
+	// OnJobComplete is called when the run func succeeds.
+	OnJobComplete func(ctx context.Context, job JobInfo)
+	// OnJobFail is called every time the run func returns an error.
+	OnJobFail func(ctx context.Context, job JobInfo, err error)
+	// OnJobRetry is called after OnJobFail when the job will run again,
+	// retried by the queue or rejected for redelivery.
+	OnJobRetry func(ctx context.Context, job JobInfo, err error)
+	// OnJobDeadLetter is called after OnJobFail when the job failed for
+	// good and its message was moved to the dead letter queue.
+	OnJobDeadLetter func(ctx context.Context, job JobInfo, err error)
+	// OnGroupComplete is called by the worker that finished the last job
+	// of a group, see QueueGroup.
+	OnGroupComplete func(ctx context.Context, group GroupInfo)
+}
+
+func (h Hooks) start(ctx context.Context, job JobInfo) {
+	if h.OnJobStart != nil {
+		h.OnJobStart(ctx, job)
+	}
+}
+
+// finish calls the hooks of a job whose run func returned err.
+func (h Hooks) finish(ctx context.Context, job JobInfo, err error) {
+	if err == nil {
+		if h.OnJobComplete != nil {
+			h.OnJobComplete(ctx, job)
+		}
+		return
+	}
+	if h.OnJobFail != nil {
+		h.OnJobFail(ctx, job, err)
+	}
+}
+
+func (h Hooks) retry(ctx context.Context, job JobInfo, err error) {
+	if h.OnJobRetry != nil {
+		h.OnJobRetry(ctx, job, err)
+	}
+}
+
+func (h Hooks) deadLetter(ctx context.Context, job JobInfo, err error) {
+	if h.OnJobDeadLetter != nil {
+		h.OnJobDeadLetter(ctx, job, err)
+	}
+}
+
+func (h Hooks) groupComplete(ctx context.Context, group GroupInfo) {
+	if h.OnGroupComplete != nil {
+		h.OnGroupComplete(ctx, group)
+	}
+}
@@ -0,0 +1,108 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestHooks(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var events []string
+	record := func(event string) func(context.Context, JobInfo, error) {
+		return func(_ context.Context, info JobInfo, _ error) {
+			events = append(events, event+" "+string(info.Payload))
+		}
+	}
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("hooks"),
+		WithDeliveryMode(List),
+		WithHooks(Hooks{
+			OnJobStart: func(_ context.Context, info JobInfo) {
+				assert.NotEmpty(t, info.ID)
+				assert.Equal(t, "hooks", info.Channel)
+				events = append(events, "start "+string(info.Payload))
+			},
+			OnJobComplete: func(_ context.Context, info JobInfo) {
+				events = append(events, "complete "+string(info.Payload))
+			},
+			OnJobFail:       record("fail"),
+			OnJobRetry:      record("retry"),
+			OnJobDeadLetter: record("dead"),
+		}),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			if string(m.Payload()) == "bad" {
+				return errors.New("failed")
+			}
+			return nil
+		}),
+	)
+
+	ok := job.NewMessage(mockMessage{Message: "ok"})
+	require.NoError(t, w.Queue(&ok))
+	bad := job.NewMessage(mockMessage{Message: "bad"}, job.AllowOption{
+		RetryCount: job.Int64(1),
+	})
+	require.NoError(t, w.Queue(&bad))
+
+	n, err := w.DrainOnce(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{
+		"start ok", "complete ok",
+		"start bad", "fail bad", "retry bad",
+		"start bad", "fail bad", "dead bad",
+	}, events)
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestErrorHandler(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var errs []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("error-handler"),
+		WithDeliveryMode(List),
+		WithErrorHandler(func(_ context.Context, msg core.QueuedMessage, err error) {
+			errs = append(errs, string(msg.(core.TaskMessage).Payload())+": "+err.Error())
+		}),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			switch string(m.Payload()) {
+			case "fail":
+				return errors.New("failed")
+			case "panic":
+				panic("boom")
+			}
+			return nil
+		}),
+	)
+
+	for _, v := range []string{"ok", "fail", "panic"} {
+		m := job.NewMessage(mockMessage{Message: v})
+		require.NoError(t, w.Queue(&m))
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		if v == "panic" {
+			assert.PanicsWithValue(t, "boom", func() { _ = w.Run(ctx, task) })
+			continue
+		}
+		_ = w.Run(ctx, task)
+	}
+
+	assert.Equal(t, []string{"fail: failed", "panic: panic: boom"}, errs)
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,79 @@
+package redisdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+)
+
+const (
+	idempotencyRunning = "running"
+	idempotencyDone    = "done"
+)
+
+// idempotency detects messages that were already processed.
+type idempotency struct {
+	enabled bool
+	key     func(core.TaskMessage) string
+	ttl     time.Duration
+}
+
+// jobKey returns the ID identifying the job of task for idempotency and
+// cancellation, the one assigned when it was queued by default.
+func (w *Worker) jobKey(task core.TaskMessage) string {
+	if w.opts.idempotency.key != nil {
+		return w.opts.idempotency.key(task)
+	}
+	if v, ok := w.deliveries.Load(task); ok {
+		return v.(*delivery).env.ID
+	}
+	return ""
+}
+
+func processedKey(channel, id string) string {
+	return channel + ":processed:" + id
+}
+
+// claimJob marks the job of task as running on this worker. It returns
+// false when the job was already processed or is running elsewhere, and
+// the key to pass to finishJob otherwise. Jobs without an ID are always
+// run.
+func (w *Worker) claimJob(ctx context.Context, channel string, task core.TaskMessage) (string, bool, error) {
+	id := w.jobKey(task)
+	if id == "" {
+		return "", true, nil
+	}
+	key := processedKey(channel, id)
+
+	// the claim expires with the job, so the message is processed again
+	// when it is redelivered after this worker crashed
+	ok, err := w.rdb.SetNX(ctx, key, idempotencyRunning, leaseFor(ctx)).Result()
+	if err != nil || ok {
+		return key, ok, err
+	}
+
+	state, err := w.rdb.Get(ctx, key).Result()
+	if err != nil {
+		return "", false, err
+	}
+	w.log.Info("skipping duplicate job", "channel", channel, "job_id", id, "state", state)
+	return "", false, nil
+}
+
+// finishJob remembers a successful job for the configured TTL, and
+// releases the claim of a failed one so that it can be retried.
+func (w *Worker) finishJob(key string, runErr error) {
+	if key == "" {
+		return
+	}
+	var err error
+	if runErr == nil {
+		err = w.rdb.Set(context.Background(), key, idempotencyDone, w.opts.idempotency.ttl).Err()
+	} else {
+		err = w.rdb.Del(context.Background(), key).Err()
+	}
+	if err != nil {
+		w.log.Error("failed to record job state", "error", err)
+	}
+}
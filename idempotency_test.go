@@ -0,0 +1,50 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var runs []string
+	fail := true
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("idempotent"),
+		WithIdempotencyKey(func(m core.TaskMessage) string {
+			return string(m.Payload())
+		}, time.Minute),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			runs = append(runs, string(m.Payload()))
+			if fail {
+				return errors.New("failed")
+			}
+			return nil
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "order-1"})
+	// a failed job can run again
+	assert.Error(t, w.Run(ctx, &m))
+	fail = false
+	assert.NoError(t, w.Run(ctx, &m))
+	// the redelivered message is skipped
+	redelivered := job.NewMessage(mockMessage{Message: "order-1"})
+	assert.NoError(t, w.Run(ctx, &redelivered))
+
+	assert.Equal(t, []string{"order-1", "order-1"}, runs)
+	assert.Equal(t, idempotencyDone, w.Redis().Get(ctx, processedKey("idempotent", "order-1")).Val())
+	assert.NoError(t, w.Shutdown())
+}
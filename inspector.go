@@ -0,0 +1,363 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// JobState is the state of a job in Redis.
+type JobState string
+
+const (
+	// JobPending jobs wait to be taken by a worker.
+	JobPending JobState = "pending"
+	// JobActive jobs were taken by a worker and are not settled yet.
+	JobActive JobState = "active"
+	// JobRetry jobs were rejected for redelivery and wait for their delay
+	// to elapse, see Retry.
+	JobRetry JobState = "retry"
+	// JobDead jobs failed for good and are in the dead letter queue.
+	JobDead JobState = "dead"
+)
+
+// JobStates lists the states reported by the Inspector.
+var JobStates = []JobState{JobPending, JobActive, JobRetry, JobDead}
+
+// JobDetails describes a job listed by the Inspector.
+type JobDetails struct {
+	// ID is the ID assigned when the job was queued, see QueueWithID.
+	ID      string   `json:"id,omitempty"`
+	Channel string   `json:"channel"`
+	State   JobState `json:"state"`
+	// Payload is the body of the job, or the raw message when it cannot
+	// be decoded.
+	Payload []byte `json:"payload"`
+	// EnqueuedAt is read from the job ID, it is zero for jobs without ID.
+	EnqueuedAt time.Time `json:"enqueued_at,omitempty"`
+	// Attempts counts the deliveries of active stream jobs.
+	Attempts int64 `json:"attempts,omitempty"`
+	// LastError is the error of the last failed run, when it is known.
+	LastError string `json:"last_error,omitempty"`
+	// Consumer is the worker processing an active job.
+	Consumer string `json:"consumer,omitempty"`
+	// NextRunAt is when a retry job is delivered again.
+	NextRunAt time.Time `json:"next_run_at,omitempty"`
+	// FailedAt is when a dead job was dead-lettered.
+	FailedAt time.Time `json:"failed_at,omitempty"`
+}
+
+// ErrJobNotFound is returned by Inspector.Job when no job has the ID.
+var ErrJobNotFound = errors.New("redisdb: job not found")
+
+// jobLookupLimit bounds the jobs of each state and channel searched by
+// Inspector.Job.
+const jobLookupLimit = 10000
+
+// ChannelCounts is the number of jobs of a channel in each state.
+type ChannelCounts struct {
+	Channel string             `json:"channel"`
+	Jobs    map[JobState]int64 `json:"jobs"`
+}
+
+// Inspector lists and counts the jobs of the channels of a worker without
+// consuming them, for dashboards and operations. It is created from the
+// same options as the worker and does not start one.
+type Inspector struct {
+	w *Worker
+}
+
+// NewInspector creates an Inspector for the channels and delivery mode set
+// by the options.
+func NewInspector(opts ...Option) *Inspector {
+	var err error
+	w := &Worker{opts: newOptions(opts...)}
+	w.log = newLog(w.opts)
+	w.rdb, err = newClient(w.opts)
+	if err != nil {
+		w.opts.logger.Fatal(err)
+	}
+	if err := w.rdb.Ping(context.Background()).Err(); err != nil {
+		w.opts.logger.Fatal(err)
+	}
+	w.functions = w.loadFunctions(context.Background())
+	return &Inspector{w: w}
+}
+
+// Close closes the Redis client of the inspector.
+func (i *Inspector) Close() error {
+	return closeClient(i.w.rdb)
+}
+
+// ListChannels returns the declared channels, see Worker.ListChannels.
+func (i *Inspector) ListChannels(ctx context.Context) ([]ChannelInfo, error) {
+	return i.w.ListChannels(ctx)
+}
+
+// Purge deletes the waiting jobs of the channels, see Worker.Purge.
+func (i *Inspector) Purge(ctx context.Context) (int64, error) {
+	return i.w.Purge(ctx)
+}
+
+// RequeueDead moves up to n dead jobs of every channel back to it, see
+// Worker.RequeueDead.
+func (i *Inspector) RequeueDead(ctx context.Context, n int) (int64, error) {
+	return i.w.RequeueDead(ctx, n)
+}
+
+// Counts returns the number of jobs in each state for every channel.
+func (i *Inspector) Counts(ctx context.Context) ([]ChannelCounts, error) {
+	w := i.w
+	res := make([]ChannelCounts, 0, len(w.opts.channels))
+	for _, channel := range w.opts.channels {
+		c := ChannelCounts{Channel: channel, Jobs: make(map[JobState]int64, len(JobStates))}
+		if w.opts.mode != PubSub {
+			var (
+				waiting, inflight int64
+				err               error
+			)
+			if w.opts.mode == Stream {
+				waiting, inflight, err = w.streamDepth(ctx, channel)
+			} else {
+				waiting, inflight, err = w.listDepth(ctx, channel)
+			}
+			if err != nil {
+				return nil, err
+			}
+			dead, err := w.rdb.XLen(ctx, deadKey(channel)).Result()
+			if err != nil {
+				return nil, err
+			}
+			c.Jobs[JobPending] = waiting
+			c.Jobs[JobActive] = inflight
+			c.Jobs[JobDead] = dead
+		}
+		retry, err := w.rdb.ZCard(ctx, delayedKey(channel)).Result()
+		if err != nil {
+			return nil, err
+		}
+		c.Jobs[JobRetry] = retry
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// List returns up to n jobs in the given state on each channel.
+func (i *Inspector) List(ctx context.Context, state JobState, n int) ([]JobDetails, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	var res []JobDetails
+	for _, channel := range i.w.opts.channels {
+		var (
+			jobs []JobDetails
+			err  error
+		)
+		switch state {
+		case JobPending:
+			jobs, err = i.listPending(ctx, channel, n)
+		case JobActive:
+			jobs, err = i.listActive(ctx, channel, n)
+		case JobRetry:
+			jobs, err = i.listRetry(ctx, channel, n)
+		case JobDead:
+			jobs, err = i.listDead(ctx, channel, n)
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, jobs...)
+	}
+	return res, nil
+}
+
+func (i *Inspector) listPending(ctx context.Context, channel string, n int) ([]JobDetails, error) {
+	var (
+		data [][]byte
+		err  error
+	)
+	switch i.w.opts.mode {
+	case List:
+		data, err = i.w.peekList(ctx, channel, n)
+	case Stream:
+		data, err = i.w.peekStream(ctx, channel, n)
+	}
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]JobDetails, 0, len(data))
+	for _, d := range data {
+		jobs = append(jobs, i.w.newJobDetails(channel, JobPending, d))
+	}
+	return jobs, nil
+}
+
+func (i *Inspector) listActive(ctx context.Context, channel string, n int) ([]JobDetails, error) {
+	switch i.w.opts.mode {
+	case List:
+		return i.listActiveList(ctx, channel, n)
+	case Stream:
+		return i.listActiveStream(ctx, channel, n)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) listActiveList(ctx context.Context, channel string, n int) ([]JobDetails, error) {
+	rdb := i.w.rdb
+	consumers, err := rdb.ZRange(ctx, consumersKey(channel), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var jobs []JobDetails
+	for _, consumer := range consumers {
+		values, err := rdb.LRange(ctx, processingKey(channel, consumer), 0, int64(n-len(jobs)-1)).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			j := i.w.newJobDetails(channel, JobActive, []byte(v))
+			j.Consumer = consumer
+			jobs = append(jobs, j)
+		}
+		if len(jobs) >= n {
+			break
+		}
+	}
+	return jobs, nil
+}
+
+func (i *Inspector) listActiveStream(ctx context.Context, channel string, n int) ([]JobDetails, error) {
+	rdb := i.w.rdb
+	pending, err := rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: channel,
+		Group:  i.w.opts.consumerGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(n),
+	}).Result()
+	if isReplyError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.XMessageSliceCmd, 0, len(pending))
+	_, err = rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, p := range pending {
+			cmds = append(cmds, pipe.XRangeN(ctx, channel, p.ID, p.ID, 1))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]JobDetails, 0, len(pending))
+	for k, cmd := range cmds {
+		entries := cmd.Val()
+		if len(entries) == 0 {
+			// trimmed while pending
+			continue
+		}
+		payload, _ := entries[0].Values[streamPayloadField].(string)
+		j := i.w.newJobDetails(channel, JobActive, []byte(payload))
+		j.Consumer = pending[k].Consumer
+		j.Attempts = pending[k].RetryCount
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func (i *Inspector) listRetry(ctx context.Context, channel string, n int) ([]JobDetails, error) {
+	members, err := i.w.rdb.ZRangeWithScores(ctx, delayedKey(channel), 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]JobDetails, 0, len(members))
+	for _, z := range members {
+		member, _ := z.Member.(string)
+		_, data, _ := strings.Cut(member, delayedSeparator)
+		j := i.w.newJobDetails(channel, JobRetry, []byte(data))
+		j.NextRunAt = time.UnixMilli(int64(z.Score))
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func (i *Inspector) listDead(ctx context.Context, channel string, n int) ([]JobDetails, error) {
+	if i.w.opts.mode == PubSub {
+		return nil, nil
+	}
+	entries, err := i.w.rdb.XRangeN(ctx, deadKey(channel), "-", "+", int64(n)).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]JobDetails, 0, len(entries))
+	for _, e := range entries {
+		payload, _ := e.Values[streamPayloadField].(string)
+		j := i.w.newJobDetails(channel, JobDead, []byte(payload))
+		j.FailedAt = streamIDTime(e.ID)
+		jobs = append(jobs, j)
+	}
+
+	// the errors recorded by the workers, see Failure
+	errs := make([]*redis.StringCmd, len(jobs))
+	_, err = i.w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, j := range jobs {
+			if j.ID != "" {
+				errs[k] = pipe.HGet(ctx, i.w.opts.key(failureKey(j.ID)), "last_error")
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	for k, cmd := range errs {
+		if cmd != nil {
+			jobs[k].LastError = cmd.Val()
+		}
+	}
+	return jobs, nil
+}
+
+// Job returns the job with the given ID, looking through the first
+// 10000 jobs of each state on every channel.
+func (i *Inspector) Job(ctx context.Context, id string) (JobDetails, error) {
+	if id == "" {
+		return JobDetails{}, ErrJobNotFound
+	}
+	for _, state := range JobStates {
+		jobs, err := i.List(ctx, state, jobLookupLimit)
+		if err != nil {
+			return JobDetails{}, err
+		}
+		for _, j := range jobs {
+			if j.ID != id {
+				continue
+			}
+			if j.LastError == "" {
+				if j.LastError, err = i.lastError(ctx, id); err != nil {
+					return JobDetails{}, err
+				}
+			}
+			return j, nil
+		}
+	}
+	return JobDetails{}, ErrJobNotFound
+}
+
+// newJobDetails decodes a serialized job.
+func (w *Worker) newJobDetails(channel string, state JobState, data []byte) JobDetails {
+	j := JobDetails{Channel: channel, State: state, Payload: data}
+	if m, env, err := w.decode(data); err == nil {
+		j.Payload = m.Body
+		j.ID = env.ID
+		j.EnqueuedAt = env.EnqueuedAt
+	}
+	return j
+}
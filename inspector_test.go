@@ -0,0 +1,87 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestInspector(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			opts := []Option{
+				WithAddr(endpoint),
+				WithChannel("inspect-" + mode.String()),
+				WithDeliveryMode(mode),
+				WithConsumerName("inspected"),
+			}
+			w := NewWorker(append(opts, WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+				switch string(m.Payload()) {
+				case "dead":
+					return errors.New("failed")
+				case "retry":
+					return Retry{After: time.Hour}
+				}
+				return nil
+			}))...)
+
+			ids := map[string]string{}
+			for _, v := range []string{"dead", "retry", "active", "pending"} {
+				m := job.NewMessage(mockMessage{Message: v})
+				id, err := w.QueueWithID(ctx, &m)
+				require.NoError(t, err)
+				ids[v] = id
+			}
+			for i := 0; i < 3; i++ {
+				task, err := w.Fetch(ctx, time.Second)
+				require.NoError(t, err)
+				if i < 2 {
+					_ = w.Run(ctx, task)
+				}
+			}
+
+			i := NewInspector(opts...)
+			counts, err := i.Counts(ctx)
+			require.NoError(t, err)
+			require.Len(t, counts, 1)
+			assert.Equal(t, map[JobState]int64{
+				JobPending: 1,
+				JobActive:  1,
+				JobRetry:   1,
+				JobDead:    1,
+			}, counts[0].Jobs)
+
+			for _, state := range JobStates {
+				jobs, err := i.List(ctx, state, 10)
+				require.NoError(t, err)
+				require.Len(t, jobs, 1, state)
+				name := string(state)
+				assert.Equal(t, name, string(jobs[0].Payload))
+				assert.Equal(t, ids[name], jobs[0].ID)
+				assert.Equal(t, state, jobs[0].State)
+				assert.WithinDuration(t, time.Now(), jobs[0].EnqueuedAt, time.Minute)
+			}
+
+			jobs, err := i.List(ctx, JobActive, 10)
+			require.NoError(t, err)
+			assert.Equal(t, "inspected", jobs[0].Consumer)
+			jobs, err = i.List(ctx, JobRetry, 10)
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), jobs[0].NextRunAt, time.Minute)
+
+			assert.NoError(t, i.Close())
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+)
+
+// jsonPayloadTTL bounds how long the RedisJSON documents of the payloads
+// are kept, so the documents of purged or lost jobs are eventually removed.
+const jsonPayloadTTL = 7 * 24 * time.Hour
+
+func jsonPayloadKey(channel, id string) string {
+	return channel + ":payload:" + id
+}
+
+// storeJSONPayload moves the JSON payload of a job to a RedisJSON document
+// when it is at least the size set by WithJSONPayloads, records the
+// document key in the envelope and returns the job without its payload.
+func (w *Worker) storeJSONPayload(ctx context.Context, channel string, m *job.Message, env *envelope) (*job.Message, error) {
+	if w.opts.mode != List && w.opts.mode != Stream {
+		return m, nil
+	}
+	if w.opts.jsonPayloadSize <= 0 || len(m.Body) < w.opts.jsonPayloadSize ||
+		env.ID == "" || !json.Valid(m.Body) {
+		return m, nil
+	}
+
+	key := jsonPayloadKey(channel, env.ID)
+	_, err := w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.JSONSet(ctx, key, "$", m.Body)
+		pipe.Expire(ctx, key, jsonPayloadTTL)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	env.PayloadKey = key
+	// the message belongs to the caller
+	stored := *m
+	stored.Body = nil
+	return &stored, nil
+}
+
+// loadJSONPayload reads the payload of a job stored by storeJSONPayload.
+func (w *Worker) loadJSONPayload(ctx context.Context, m *job.Message, env envelope) error {
+	if env.PayloadKey == "" {
+		return nil
+	}
+	body, err := w.rdb.JSONGet(ctx, env.PayloadKey).Result()
+	if errors.Is(err, redis.Nil) || err == nil && body == "" {
+		return ErrPayloadNotFound
+	}
+	if err != nil {
+		return err
+	}
+	m.Body = []byte(body)
+	return nil
+}
+
+// dropJSONPayload deletes the payload document of a finished job.
+func (w *Worker) dropJSONPayload(ctx context.Context, env envelope) {
+	if env.PayloadKey == "" {
+		return
+	}
+	if err := w.rdb.Del(ctx, env.PayloadKey).Err(); err != nil {
+		w.log.Error("failed to delete payload document", "key", env.PayloadKey, "error", err)
+	}
+}
+
+// ErrPayloadNotFound is returned when the RedisJSON document of a payload
+// expired or was deleted, see WithJSONPayloads.
+var ErrPayloadNotFound = errors.New("redisdb: payload document not found")
+
+// PayloadField reads the JSONPath path of the payload stored as a RedisJSON
+// document of the job with the given ID, without loading the whole
+// payload, see WithJSONPayloads. The result is a JSON array of the matched
+// values.
+func (i *Inspector) PayloadField(ctx context.Context, id, path string) (json.RawMessage, error) {
+	for _, channel := range i.w.opts.channels {
+		v, err := i.w.rdb.JSONGet(ctx, jsonPayloadKey(channel, id), path).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v != "" {
+			return json.RawMessage(v), nil
+		}
+	}
+	return nil, ErrPayloadNotFound
+}
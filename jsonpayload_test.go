@@ -0,0 +1,69 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestJSONPayloads(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisImage(ctx, t, "redis/redis-stack-server:7.2.0-v13")
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "json-payloads"
+	opts := []Option{
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(Stream),
+		WithJSONPayloads(16),
+	}
+	var payload string
+	w := NewWorker(append(opts, WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+		payload = string(m.Payload())
+		return nil
+	}))...)
+	defer w.Shutdown()
+	i := NewInspector(opts...)
+	defer i.Close()
+
+	m := job.NewMessage(mockMessage{Message: `{"user":{"id":42,"name":"gopher"}}`})
+	id, err := w.QueueWithID(ctx, &m)
+	require.NoError(t, err)
+
+	// the entry only references the document
+	entries, err := w.Redis().XRange(ctx, channel, "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	stored, env, err := w.decode([]byte(entries[0].Values[streamPayloadField].(string)))
+	require.NoError(t, err)
+	assert.Empty(t, stored.Body)
+	assert.Equal(t, jsonPayloadKey(channel, id), env.PayloadKey)
+
+	field, err := i.PayloadField(ctx, id, "$.user.id")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[42]`, string(field))
+
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Run(ctx, task))
+	assert.JSONEq(t, `{"user":{"id":42,"name":"gopher"}}`, payload)
+
+	// the document is deleted once the job succeeded
+	n, err := w.Redis().Exists(ctx, jsonPayloadKey(channel, id)).Result()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// small payloads stay inline
+	m = job.NewMessage(mockMessage{Message: `{}`})
+	id, err = w.QueueWithID(ctx, &m)
+	require.NoError(t, err)
+	_, err = i.PayloadField(ctx, id, "$")
+	assert.ErrorIs(t, err, ErrPayloadNotFound)
+}
@@ -0,0 +1,151 @@
+package redisdb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyspaceFallbackInterval is how often idle list workers look for messages
+// when they wait for keyspace notifications, which are lost while the
+// subscription reconnects.
+const keyspaceFallbackInterval = time.Second
+
+// waker wakes all the goroutines waiting for an event.
+type waker struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newWaker() *waker {
+	return &waker{ch: make(chan struct{})}
+}
+
+// wait returns a channel closed on the next event. Take it before looking
+// for work, so an event arriving meanwhile is not missed.
+func (k *waker) wait() <-chan struct{} {
+	if k == nil {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.ch
+}
+
+func (k *waker) wake() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	close(k.ch)
+	k.ch = make(chan struct{})
+}
+
+// keyspaceWatcher wakes the idle consumers on the keyspace notifications
+// of the channel lists and delayed sets, see WithKeyspaceNotifications.
+type keyspaceWatcher struct {
+	pubsub *redis.PubSub
+	// lists is woken when messages are pushed onto a channel, delayed
+	// when messages are scheduled.
+	lists   *waker
+	delayed *waker
+	// kinds maps the subscribed channels to the waker of their key.
+	kinds map[string]*waker
+}
+
+// watchKeyspace subscribes to the keyspace notifications of the channels.
+// It returns nil when they are not enabled or not supported, so the worker
+// polls as usual.
+func (w *Worker) watchKeyspace(ctx context.Context) (*keyspaceWatcher, error) {
+	if !w.opts.keyspaceNotifications {
+		return nil, nil
+	}
+	if !w.opts.flavor.keyspaceEvents() {
+		w.log.Warn("keyspace notifications are not supported by the server", "flavor", w.opts.flavor)
+		return nil, nil
+	}
+	client, ok := w.rdb.(*redis.Client)
+	if !ok {
+		// notifications are not broadcast across the nodes of a cluster
+		w.log.Warn("keyspace notifications are not supported on redis cluster")
+		return nil, nil
+	}
+	if flags, err := client.ConfigGet(ctx, "notify-keyspace-events").Result(); err == nil {
+		v := flags["notify-keyspace-events"]
+		if !strings.Contains(v, "K") || !(strings.Contains(v, "A") || strings.Contains(v, "l") && strings.Contains(v, "z")) {
+			w.log.Warn("keyspace notifications are disabled on the server, set notify-keyspace-events to Klz", "notify-keyspace-events", v)
+		}
+	}
+
+	k := &keyspaceWatcher{
+		lists:   newWaker(),
+		delayed: newWaker(),
+		kinds:   make(map[string]*waker),
+	}
+	prefix := "__keyspace@" + strconv.Itoa(client.Options().DB) + "__:"
+	if w.opts.mode == List {
+		for _, channel := range w.opts.channels {
+			k.kinds[prefix+channel] = k.lists
+		}
+	}
+	for _, channel := range w.opts.channels {
+		k.kinds[prefix+delayedKey(channel)] = k.delayed
+	}
+	names := make([]string, 0, len(k.kinds))
+	for name := range k.kinds {
+		names = append(names, name)
+	}
+	k.pubsub = client.Subscribe(ctx, names...)
+	if err := k.pubsub.Ping(ctx); err != nil {
+		_ = k.pubsub.Close()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go k.run(w.stop, &w.wg)
+	return k, nil
+}
+
+func (k *keyspaceWatcher) run(stop <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer k.pubsub.Close()
+	msgs := k.pubsub.Channel()
+	for {
+		select {
+		case <-stop:
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			switch msg.Payload {
+			case "lpush", "rpush", "linsert", "zadd":
+				if wk := k.kinds[msg.Channel]; wk != nil {
+					wk.wake()
+				}
+			}
+		}
+	}
+}
+
+// nextDelayed returns when the first delayed message of the consumed
+// channels is due, zero when there is none.
+func (w *Worker) nextDelayed(ctx context.Context) (time.Time, error) {
+	var next time.Time
+	for _, channel := range w.opts.channels {
+		first, err := w.rdb.ZRangeWithScores(ctx, delayedKey(channel), 0, 0).Result()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if len(first) == 0 {
+			continue
+		}
+		at := time.UnixMilli(int64(first[0].Score))
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,49 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestKeyspaceNotifications(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("keyspace-a", "keyspace-b"),
+		WithDeliveryMode(List),
+		WithKeyspaceNotifications(),
+	)
+	defer w.Shutdown()
+	require.NoError(t, w.Redis().ConfigSet(ctx, "notify-keyspace-events", "Klz").Err())
+	require.NotNil(t, w.keyspace)
+
+	// an idle worker wakes up when a message is pushed
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		assert.NoError(t, w.Queue(&m))
+	}()
+	start := time.Now()
+	task, err := w.Fetch(ctx, 5*time.Second)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 600*time.Millisecond)
+	require.NoError(t, w.Run(ctx, task))
+
+	// delayed messages are moved when due
+	m := job.NewMessage(mockMessage{Message: "bar"})
+	start = time.Now()
+	require.NoError(t, w.schedule(ctx, "keyspace-b", m.Bytes(), start.Add(300*time.Millisecond)))
+	task, err = w.Fetch(ctx, 5*time.Second)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 600*time.Millisecond)
+	assert.NoError(t, w.Run(ctx, task))
+}
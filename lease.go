@@ -0,0 +1,40 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// heldSlot is a semaphore slot held by a running job.
+type heldSlot struct {
+	sem   *semaphore
+	token string
+}
+
+// ExtendLease tells Redis that the job running with ctx is still alive, for
+// jobs that legitimately run longer than expected. It resets the idle time
+// of its stream entry, so it is not claimed by another consumer, and
+// extends its concurrency slot and ordering locks to expire d from now.
+func ExtendLease(ctx context.Context, d time.Duration) error {
+	a := AckFromContext(ctx)
+	if a == nil || a.m == nil {
+		return ErrNoDelivery
+	}
+	w := a.w
+
+	var errs []error
+	for _, s := range a.slots {
+		errs = append(errs, s.sem.extend(ctx, s.token, d))
+	}
+	if v, ok := w.deliveries.Load(a.m); ok {
+		dv := v.(*delivery)
+		errs = append(errs, w.broker.touch(ctx, dv))
+		if dv.lock != nil {
+			for i, sem := range dv.lock.sems {
+				errs = append(errs, sem.extend(ctx, dv.lock.tokens[i], d))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
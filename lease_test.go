@@ -0,0 +1,58 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestExtendLease(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("lease"),
+		WithDeliveryMode(Stream),
+		WithMaxConcurrency(1),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			time.Sleep(300 * time.Millisecond)
+			assert.NoError(t, ExtendLease(ctx, time.Hour))
+
+			rdb := Client(ctx)
+			pending := rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream: "lease",
+				Group:  defaultConsumerGroup,
+				Start:  "-",
+				End:    "+",
+				Count:  1,
+			}).Val()
+			assert.Len(t, pending, 1)
+			assert.Less(t, pending[0].Idle, 300*time.Millisecond)
+
+			slots := rdb.ZRangeWithScores(ctx, concurrencyKey("lease"), 0, -1).Val()
+			assert.Len(t, slots, 1)
+			assert.Greater(t, slots[0].Score, float64(time.Now().Add(50*time.Minute).UnixMilli()))
+			return nil
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.Queue(&m))
+	task, err := w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+	runCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.NoError(t, w.Run(runCtx, task))
+
+	assert.Equal(t, ErrNoDelivery, ExtendLease(ctx, time.Minute))
+	assert.NoError(t, w.Shutdown())
+}
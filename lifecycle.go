@@ -0,0 +1,64 @@
+package redisdb
+
+import (
+	"net"
+	"os"
+)
+
+// State is a lifecycle state reported by the worker.
+type State string
+
+const (
+	// StateReady is reported once the worker is connected and can receive messages.
+	StateReady State = "READY"
+	// StateDraining is reported when Shutdown starts and no new work is accepted.
+	StateDraining State = "DRAINING"
+	// StateStopped is reported once the worker has released its Redis connections.
+	StateStopped State = "STOPPED"
+)
+
+func (w *Worker) notify(s State) {
+	if w.opts.lifecycleHook != nil {
+		w.opts.lifecycleHook(s)
+	}
+}
+
+// SystemdNotify returns a lifecycle hook that reports the worker state to
+// systemd using the sd_notify protocol. It does nothing when the process
+// was not started by systemd with NotifyAccess, i.e. NOTIFY_SOCKET is unset.
+func SystemdNotify() func(State) {
+	return func(s State) {
+		var msg string
+		switch s {
+		case StateReady:
+			msg = "READY=1"
+		case StateDraining:
+			msg = "STOPPING=1"
+		case StateStopped:
+			msg = "STATUS=stopped"
+		default:
+			return
+		}
+		_ = sdNotify(msg)
+	}
+}
+
+func sdNotify(state string) error {
+	name := os.Getenv("NOTIFY_SOCKET")
+	if name == "" {
+		return nil
+	}
+	// a leading @ denotes a socket in the abstract namespace
+	if name[0] == '@' {
+		name = "\x00" + name[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
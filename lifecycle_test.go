@@ -0,0 +1,53 @@
+package redisdb
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestLifecycleHook(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var states []State
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("lifecycle"),
+		WithLifecycleHook(func(s State) {
+			states = append(states, s)
+		}),
+	)
+	assert.Equal(t, []State{StateReady}, states)
+	assert.NoError(t, w.Shutdown())
+	assert.Equal(t, []State{StateReady, StateDraining, StateStopped}, states)
+}
+
+func TestSystemdNotify(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", addr)
+
+	hook := SystemdNotify()
+	buf := make([]byte, 64)
+	for state, want := range map[State]string{
+		StateReady:    "READY=1",
+		StateDraining: "STOPPING=1",
+		StateStopped:  "STATUS=stopped",
+	} {
+		hook(state)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(buf[:n]))
+	}
+}
@@ -0,0 +1,308 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-queue/queue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ broker = (*listBroker)(nil)
+
+const (
+	// consumerTTL is how long a list consumer may miss heartbeats before
+	// its processing list is handed back to the queue.
+	consumerTTL       = 30 * time.Second
+	heartbeatInterval = consumerTTL / 3
+	// listPollInterval is how often empty lists are checked again when
+	// consuming several channels, since BLMOVE only watches one key.
+	listPollInterval = 100 * time.Millisecond
+)
+
+// listClaimScript moves a message from the list KEYS[1] to the processing
+// list KEYS[2] and records the claim of the consumer ARGV[1] at ARGV[2] in
+// KEYS[3], so a message is never popped without its consumer being alive.
+var listClaimScript = newQueueScript("list_claim", `
+local msg = redis.call('RPOPLPUSH', KEYS[1], KEYS[2])
+if msg then
+  redis.call('ZADD', KEYS[3], ARGV[2], ARGV[1])
+end
+return msg
+`)
+
+// listRecoverScript hands the processing list KEYS[2] back to the list
+// KEYS[3] when the consumer ARGV[1] has not been seen in KEYS[1] since
+// ARGV[2], checked in the same step so a consumer claiming a message
+// meanwhile keeps it.
+var listRecoverScript = newQueueScript("list_recover", `
+local seen = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not seen or tonumber(seen) >= tonumber(ARGV[2]) then
+  return 0
+end
+local n = 0
+while redis.call('RPOPLPUSH', KEYS[2], KEYS[3]) do
+  n = n + 1
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+return n
+`)
+
+// listBroker implements a reliable queue on top of Redis lists. Messages are
+// atomically moved to a per-consumer processing list when popped and only
+// removed from it once handled, so the jobs of a crashed consumer can be
+// recovered by the next worker that starts, the consumer itself when it
+// restarts with the same name, or by the running ones with AtLeastOnce. Popping and recording the
+// claim run in one script, see listClaimScript.
+type listBroker struct {
+	rdb       redis.Cmdable
+	functions *functionLibrary
+	channels  *channelSet
+	consumer  string
+	log       *slog.Logger
+	// wake is woken when messages are pushed, see
+	// WithKeyspaceNotifications.
+	wake *waker
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newListBroker returns a broker consuming the given lists.
+func newListBroker(ctx context.Context, w *Worker, channels []string) (*listBroker, error) {
+	b := &listBroker{
+		rdb:       w.rdb,
+		functions: w.functions,
+		channels:  newChannelSet(channels, w.opts.channelStrategy, &w.paused),
+		consumer:  w.opts.consumerName,
+		log:       w.log,
+		stop:      make(chan struct{}),
+	}
+	if w.keyspace != nil && w.opts.mode == List {
+		b.wake = w.keyspace.lists
+	}
+
+	for _, channel := range b.channels.names {
+		if err := b.requeueOwn(ctx, channel); err != nil {
+			return nil, err
+		}
+		if err := b.requeueExpired(ctx, channel); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.heartbeat(ctx); err != nil {
+		return nil, err
+	}
+
+	reclaim := w.opts.reclaims()
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				if err := b.heartbeat(context.Background()); err != nil {
+					b.log.Error("list consumer heartbeat failed", "consumer", b.consumer, "error", err)
+				}
+				if !reclaim {
+					continue
+				}
+				for _, channel := range b.channels.names {
+					if err := b.requeueExpired(context.Background(), channel); err != nil {
+						b.log.Error("failed to reclaim processing lists", "channel", channel, "error", err)
+					}
+				}
+			}
+		}
+	}()
+
+	return b, nil
+}
+
+func processingKey(channel, consumer string) string {
+	return channel + ":processing:" + consumer
+}
+
+func consumersKey(channel string) string {
+	return channel + ":consumers"
+}
+
+// heartbeat records that this consumer is alive.
+func (b *listBroker) heartbeat(ctx context.Context) error {
+	for _, channel := range b.channels.names {
+		err := b.rdb.ZAdd(ctx, consumersKey(channel), redis.Z{
+			Score:  float64(time.Now().Unix()),
+			Member: b.consumer,
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requeueOwn moves the processing list left by a previous run of this
+// consumer back onto the queue. It runs before the first heartbeat, which
+// would make the list look like the one of a live consumer.
+func (b *listBroker) requeueOwn(ctx context.Context, channel string) error {
+	for {
+		err := b.rdb.RPopLPush(ctx, processingKey(channel, b.consumer), channel).Err()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// requeueExpired moves the processing lists of consumers that stopped
+// sending heartbeats back onto the queue.
+func (b *listBroker) requeueExpired(ctx context.Context, channel string) error {
+	expired := strconv.FormatInt(time.Now().Add(-consumerTTL).Unix(), 10)
+	names, err := b.rdb.ZRangeByScore(ctx, consumersKey(channel), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + expired,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		keys := []string{consumersKey(channel), processingKey(channel, name), channel}
+		if err := listRecoverScript.run(ctx, b.rdb, b.functions, keys, name, expired).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *listBroker) push(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error {
+	return rdb.LPush(ctx, channel, data).Err()
+}
+
+// claim atomically pops a message of a channel into the processing list
+// and records the claim of the consumer.
+func (b *listBroker) claim(ctx context.Context, channel string) (*delivery, error) {
+	keys := []string{channel, processingKey(channel, b.consumer), consumersKey(channel)}
+	val, err := listClaimScript.run(ctx, b.rdb, b.functions, keys, b.consumer, time.Now().Unix()).Text()
+	if err != nil {
+		return nil, err
+	}
+	return &delivery{channel: channel, data: []byte(val)}, nil
+}
+
+func (b *listBroker) pop(ctx context.Context, timeout time.Duration) (*delivery, error) {
+	deadline := time.Now().Add(timeout)
+	if len(b.channels.names) == 1 && !b.channels.paused.has(b.channels.names[0]) {
+		channel := b.channels.names[0]
+		for {
+			d, err := b.claim(ctx, channel)
+			if !errors.Is(err, redis.Nil) {
+				return d, err
+			}
+
+			// BLMOVE onto the same list waits for a message without taking
+			// it, and takes its timeout in whole seconds. It blocks one
+			// second at a time, so that Shutdown and ctx are seen between
+			// them, and the rest of the timeout is polled below.
+			if time.Until(deadline) < time.Second {
+				break
+			}
+			err = b.rdb.BLMove(ctx, channel, channel, "RIGHT", "RIGHT", time.Second).Err()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return nil, err
+			}
+			select {
+			case <-b.stop:
+				return nil, queue.ErrQueueHasBeenClosed
+			case <-ctx.Done():
+				return nil, queue.ErrNoTaskInQueue
+			default:
+			}
+		}
+	}
+
+	poll := listPollInterval
+	if b.wake != nil {
+		poll = keyspaceFallbackInterval
+	}
+	for {
+		woken := b.wake.wait()
+		for _, channel := range b.channels.next() {
+			d, err := b.claim(ctx, channel)
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			return d, err
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, queue.ErrNoTaskInQueue
+		}
+		if wait > poll {
+			wait = poll
+		}
+		select {
+		case <-b.stop:
+			return nil, queue.ErrQueueHasBeenClosed
+		case <-ctx.Done():
+			return nil, queue.ErrNoTaskInQueue
+		case <-woken:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// ack removes a handled message from the processing list.
+func (b *listBroker) ack(ctx context.Context, d *delivery) error {
+	return b.rdb.LRem(ctx, processingKey(d.channel, b.consumer), 1, d.data).Err()
+}
+
+func (b *listBroker) ackPipe(ctx context.Context, pipe redis.Pipeliner, d *delivery) {
+	pipe.LRem(ctx, processingKey(d.channel, b.consumer), 1, d.data)
+}
+
+// reject moves a message that cannot be processed to the dead letter queue.
+func (b *listBroker) reject(ctx context.Context, d *delivery) error {
+	_, err := b.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, processingKey(d.channel, b.consumer), 1, d.data)
+		deadLetter(ctx, pipe, d.channel, d.data)
+		return nil
+	})
+	return err
+}
+
+// requeue moves a message from the processing list back to the end of
+// the queue it is consumed from, so it is the next one to be taken.
+func (b *listBroker) requeue(ctx context.Context, d *delivery, data []byte) error {
+	_, err := b.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, processingKey(d.channel, b.consumer), 1, d.data)
+		pipe.RPush(ctx, d.channel, data)
+		return nil
+	})
+	return err
+}
+
+// touch does nothing, processing lists are kept as long as the consumer
+// sends heartbeats.
+func (b *listBroker) touch(ctx context.Context, d *delivery) error {
+	return nil
+}
+
+func (b *listBroker) close() error {
+	close(b.stop)
+	b.wg.Wait()
+	return nil
+}
@@ -0,0 +1,134 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/golang-queue/queue"
+)
+
+// newLog returns the structured logger used by the worker: the one set by
+// WithSlog, or one writing to the queue.Logger of the worker.
+func newLog(opts options) *slog.Logger {
+	if opts.slog != nil {
+		return opts.slog
+	}
+	return slog.New(&loggerHandler{logger: opts.logger, debug: opts.debug})
+}
+
+// slogLogger adapts a *slog.Logger to the queue.Logger interface.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+var _ queue.Logger = slogLogger{}
+
+func (s slogLogger) Infof(format string, args ...any) {
+	s.l.Info(fmt.Sprintf(format, args...))
+}
+
+func (s slogLogger) Errorf(format string, args ...any) {
+	s.l.Error(fmt.Sprintf(format, args...))
+}
+
+func (s slogLogger) Fatalf(format string, args ...any) {
+	s.l.Error(fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (s slogLogger) Info(args ...any) {
+	s.l.Info(fmt.Sprint(args...))
+}
+
+func (s slogLogger) Error(args ...any) {
+	s.l.Error(fmt.Sprint(args...))
+}
+
+func (s slogLogger) Fatal(args ...any) {
+	s.l.Error(fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+// loggerHandler is a slog.Handler writing records to a queue.Logger as
+// "redisdb: message key=value ...". Warnings and errors go to Errorf, the
+// rest to Infof. Debug records are only written in debug mode.
+type loggerHandler struct {
+	logger queue.Logger
+	debug  bool
+	attrs  []slog.Attr
+	group  string
+}
+
+// Enabled implements slog.Handler.
+func (h *loggerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level > slog.LevelDebug || h.debug
+}
+
+// Handle implements slog.Handler.
+func (h *loggerHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString("redisdb: ")
+	b.WriteString(r.Message)
+	for _, a := range h.attrs {
+		writeAttr(&b, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.group, a)
+		return true
+	})
+
+	if r.Level >= slog.LevelWarn {
+		h.logger.Errorf("%s", b.String())
+	} else {
+		h.logger.Infof("%s", b.String())
+	}
+	return nil
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, prefix, ga)
+		}
+		return
+	}
+	fmt.Fprintf(b, " %s%s=%v", prefix, a.Key, a.Value)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *loggerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append(c.attrs[:len(c.attrs):len(c.attrs)], prefixAttrs(h.group, attrs)...)
+	return &c
+}
+
+// WithGroup implements slog.Handler.
+func (h *loggerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.group += name + "."
+	return &c
+}
+
+func prefixAttrs(group string, attrs []slog.Attr) []slog.Attr {
+	if group == "" {
+		return attrs
+	}
+	res := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		res = append(res, slog.Attr{Key: group + a.Key, Value: a.Value})
+	}
+	return res
+}
@@ -0,0 +1,90 @@
+package redisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type captureLogger struct {
+	infos, errors []string
+}
+
+func (l *captureLogger) Infof(format string, args ...any) {
+	l.infos = append(l.infos, fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Errorf(format string, args ...any) {
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Fatalf(format string, args ...any) { l.Errorf(format, args...) }
+func (l *captureLogger) Info(args ...any)                  { l.Infof("%s", fmt.Sprint(args...)) }
+func (l *captureLogger) Error(args ...any)                 { l.Errorf("%s", fmt.Sprint(args...)) }
+func (l *captureLogger) Fatal(args ...any)                 { l.Errorf("%s", fmt.Sprint(args...)) }
+
+func TestLoggerHandler(t *testing.T) {
+	logger := &captureLogger{}
+	log := newLog(options{logger: logger})
+
+	log.Debug("hidden")
+	log.Info("started", "channel", "foo")
+	log.With("consumer", "c1").WithGroup("job").Warn("rejected", "id", "42")
+
+	assert.Equal(t, []string{"redisdb: started channel=foo"}, logger.infos)
+	assert.Equal(t, []string{"redisdb: rejected consumer=c1 job.id=42"}, logger.errors)
+
+	log = newLog(options{logger: logger, debug: true})
+	log.Debug("shown")
+	assert.Equal(t, "redisdb: shown", logger.infos[1])
+}
+
+func TestSlog(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var buf bytes.Buffer
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("slog"),
+		WithDeliveryMode(List),
+		WithSlog(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			return Retry{After: time.Minute}
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	id, err := w.QueueWithID(ctx, &m)
+	require.NoError(t, err)
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, task))
+	assert.NoError(t, w.Shutdown())
+
+	var records []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r map[string]any
+		require.NoError(t, dec.Decode(&r))
+		records = append(records, r)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "worker started", records[0]["msg"])
+	assert.Equal(t, "job rejected for redelivery", records[1]["msg"])
+	assert.Equal(t, "WARN", records[1]["level"])
+	assert.Equal(t, "slog", records[1]["channel"])
+	assert.Equal(t, id, records[1]["job_id"])
+	assert.Equal(t, float64(1), records[1]["attempt"])
+}
@@ -0,0 +1,38 @@
+// Package memory runs redisdb workers against an in-memory Redis server,
+// miniredis, so tests exercise the whole worker without Docker. The server
+// supports most commands but not all: keep tests of cluster, sentinel or
+// server specific behavior on a real Redis, see the redistest package.
+package memory
+
+import (
+	"testing"
+
+	"github.com/golang-queue/redisdb"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// Start starts an in-memory Redis server, stopped at the end of the test,
+// and returns its address.
+func Start(tb testing.TB) string {
+	tb.Helper()
+	s, err := miniredis.Run()
+	if err != nil {
+		tb.Fatalf("memory: start server: %v", err)
+	}
+	tb.Cleanup(s.Close)
+	return s.Addr()
+}
+
+// Setup starts an in-memory Redis server and returns a worker connected
+// to it with opts, shut down at the end of the test, along with the
+// address of the server.
+func Setup(tb testing.TB, opts ...redisdb.Option) (*redisdb.Worker, string) {
+	tb.Helper()
+	addr := Start(tb)
+	w := redisdb.NewWorker(append([]redisdb.Option{redisdb.WithAddr(addr)}, opts...)...)
+	tb.Cleanup(func() {
+		_ = w.Shutdown()
+	})
+	return w, addr
+}
@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/golang-queue/redisdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type message string
+
+func (m message) Bytes() []byte   { return []byte(m) }
+func (m message) Payload() []byte { return []byte(m) }
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+
+	var payloads []string
+	w, _ := Setup(t,
+		redisdb.WithChannel("memory"),
+		redisdb.WithDeliveryMode(redisdb.List),
+		redisdb.WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			payloads = append(payloads, string(m.Payload()))
+			return nil
+		}),
+	)
+
+	m := job.NewMessage(message("foo"))
+	require.NoError(t, w.Queue(&m))
+	assert.Equal(t, int64(1), w.Redis().LLen(ctx, "memory").Val())
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"foo"}, payloads)
+}
@@ -0,0 +1,163 @@
+package redisdb
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// envelopeVersion is the version of the envelope written by this package.
+// Messages queued by older releases have no version.
+const envelopeVersion = 1
+
+// envelope holds the metadata stored with a job, see wireMessage.
+type envelope struct {
+	Version int
+	// ID identifies the job, see QueueWithID.
+	ID         string
+	EnqueuedAt time.Time
+	// Attempts counts the runs of the job before it was queued again.
+	Attempts    int
+	ContentType string
+	// Headers are the metadata headers and the context values propagated
+	// with the job.
+	Headers map[string]string
+	// Trace is the trace context of the span that queued the job.
+	Trace map[string]string
+	// PayloadKey is the RedisJSON document holding the payload, see
+	// WithJSONPayloads.
+	PayloadKey string
+	// ExpiresAt is when the job expires if it has not run, see WithJobTTL.
+	ExpiresAt time.Time
+	// Group is the ID of the group of the job, see QueueGroup.
+	Group string
+	// Next is the message queued onto NextChannel once the job succeeded,
+	// see QueueChain.
+	Next        []byte
+	NextChannel string
+}
+
+// Metadata is the envelope of a job, everything sent along with its
+// payload.
+type Metadata struct {
+	// ID is the ID of the job, see QueueWithID.
+	ID string
+	// EnqueuedAt is when the job was first queued.
+	EnqueuedAt time.Time
+	// Attempt counts the runs of the job, the current one included.
+	Attempt int
+	// ContentType describes the payload, as set by the producer.
+	ContentType string
+	// Headers are the string values sent with the job.
+	Headers map[string]string
+	// Trace is the trace context of the producer, see WithTracerProvider.
+	Trace map[string]string
+	// ExpiresAt is when the job expires if it has not run, zero when it
+	// does not, see WithJobTTL.
+	ExpiresAt time.Time
+	// Group is the ID of the group the job was queued in, see QueueGroup.
+	Group string
+}
+
+// MetadataFromContext returns the metadata of the running job. It returns
+// false when ctx does not come from a worker.
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(metadataKey{}).(Metadata)
+	return md, ok
+}
+
+// ContextWithMetadata returns a copy of ctx whose jobs are queued with the
+// content type and headers of md, the other fields are set by the worker.
+// Jobs queued from a handler inherit the metadata of the running job.
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// newEnvelope returns the envelope of a job queued with ctx.
+func (w *Worker) newEnvelope(ctx context.Context, id string) envelope {
+	md, _ := MetadataFromContext(ctx)
+	headers := maps.Clone(md.Headers)
+	for k, v := range w.headersFromContext(ctx) {
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[k] = v
+	}
+	env := envelope{
+		Version:     envelopeVersion,
+		ID:          id,
+		EnqueuedAt:  time.Now(),
+		ContentType: md.ContentType,
+		Headers:     headers,
+	}
+	if w.opts.jobTTL > 0 {
+		env.ExpiresAt = env.EnqueuedAt.Add(w.opts.jobTTL)
+	}
+	return env
+}
+
+// restamp returns the message data as queued now: its EnqueuedAt is now
+// and its TTL, see WithJobTTL, starts over. It suits messages encoded long
+// before they are queued, such as the completion job of a group.
+func (w *Worker) restamp(data []byte) []byte {
+	m, env, err := w.decode(data)
+	if err != nil {
+		return data
+	}
+	now := time.Now()
+	if !env.ExpiresAt.IsZero() {
+		env.ExpiresAt = now.Add(env.ExpiresAt.Sub(env.EnqueuedAt))
+	}
+	env.EnqueuedAt = now
+	out, err := w.encode(m, env)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// metadata returns the metadata of the attempt-th run of a job.
+func (e envelope) metadata(attempt int) Metadata {
+	return Metadata{
+		ID:          e.ID,
+		EnqueuedAt:  e.EnqueuedAt,
+		Attempt:     attempt,
+		ContentType: e.ContentType,
+		Headers:     e.Headers,
+		Trace:       e.Trace,
+		ExpiresAt:   e.ExpiresAt,
+		Group:       e.Group,
+	}
+}
+
+// contextKey is a context value propagated from producers to handlers.
+type contextKey struct {
+	name string
+	key  any
+}
+
+// headersFromContext returns the propagated values found in ctx.
+func (w *Worker) headersFromContext(ctx context.Context) map[string]string {
+	var headers map[string]string
+	for _, k := range w.opts.contextKeys {
+		v, ok := ctx.Value(k.key).(string)
+		if !ok {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[k.name] = v
+	}
+	return headers
+}
+
+// contextWithHeaders stores the propagated values of a message in ctx.
+func (w *Worker) contextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	for _, k := range w.opts.contextKeys {
+		if v, ok := headers[k.name]; ok {
+			ctx = context.WithValue(ctx, k.key, v)
+		}
+	}
+	return ctx
+}
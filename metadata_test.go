@@ -0,0 +1,134 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type tenantKey struct{}
+
+func TestContextKey(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var tenants []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("tenants"),
+		WithDeliveryMode(List),
+		WithContextKey("tenant", tenantKey{}),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			tenants = append(tenants, tenant)
+			return nil
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.QueueContext(context.WithValue(ctx, tenantKey{}, "acme"), &m))
+	assert.NoError(t, w.Queue(&m))
+
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"acme", ""}, tenants)
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestQueueWithID(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var ids []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("job-ids"),
+		WithDeliveryMode(Stream),
+		WithIdempotencyKey(nil, time.Hour),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			ids = append(ids, JobIDFromContext(ctx))
+			return nil
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	first, err := w.QueueWithID(ctx, &m)
+	assert.NoError(t, err)
+	second, err := w.QueueWithID(ctx, &m)
+	assert.NoError(t, err)
+	assert.Len(t, first, 26)
+	assert.NotEqual(t, first, second)
+
+	// jobs are cancelled by ID
+	assert.NoError(t, w.Cancel(ctx, second))
+
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{first}, ids)
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestMetadataFromContext(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var runs []Metadata
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("metadata"),
+		WithDeliveryMode(Stream),
+		WithContextKey("tenant", tenantKey{}),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			md, ok := MetadataFromContext(ctx)
+			assert.True(t, ok)
+			runs = append(runs, md)
+			if len(runs) == 1 {
+				return Retry{}
+			}
+			return nil
+		}),
+	)
+
+	_, ok := MetadataFromContext(ctx)
+	assert.False(t, ok)
+
+	queueCtx := ContextWithMetadata(ctx, Metadata{
+		ContentType: "text/plain",
+		Headers:     map[string]string{"source": "test"},
+	})
+	queueCtx = context.WithValue(queueCtx, tenantKey{}, "acme")
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	start := time.Now().Truncate(time.Millisecond)
+	id, err := w.QueueWithID(queueCtx, &m)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		task, err := w.Fetch(ctx, time.Second)
+		if assert.NoError(t, err) {
+			assert.NoError(t, w.Run(ctx, task))
+		}
+	}
+
+	if assert.Len(t, runs, 2) {
+		for i, md := range runs {
+			assert.Equal(t, id, md.ID)
+			assert.Equal(t, i+1, md.Attempt)
+			assert.Equal(t, "text/plain", md.ContentType)
+			assert.Equal(t, map[string]string{"source": "test", "tenant": "acme"}, md.Headers)
+			assert.False(t, md.EnqueuedAt.Before(start))
+		}
+		assert.Equal(t, runs[0].EnqueuedAt, runs[1].EnqueuedAt)
+	}
+	assert.NoError(t, w.Shutdown())
+}
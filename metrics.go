@@ -0,0 +1,252 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const instrumentationName = "github.com/golang-queue/redisdb"
+
+// instruments holds the OpenTelemetry instruments recorded by the worker.
+// All instruments are no-ops unless WithMeterProvider is set.
+type instruments struct {
+	published metric.Int64Counter
+	received  metric.Int64Counter
+	processed metric.Int64Counter
+	duration  metric.Float64Histogram
+	expired   metric.Int64Counter
+	meter     metric.Meter
+
+	// instruments of the messaging semantic conventions
+	publishMessages metric.Int64Counter
+	receiveMessages metric.Int64Counter
+	processDuration metric.Float64Histogram
+	acked           metric.Int64Counter
+	nacked          metric.Int64Counter
+	redelivered     metric.Int64Counter
+	latency         metric.Float64Histogram
+}
+
+func newInstruments(mp metric.MeterProvider) (*instruments, error) {
+	var err error
+	meter := mp.Meter(instrumentationName)
+	i := &instruments{meter: meter}
+
+	i.published, err = meter.Int64Counter(
+		"redisdb.messages.published",
+		metric.WithDescription("Number of messages published to Redis."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.received, err = meter.Int64Counter(
+		"redisdb.messages.received",
+		metric.WithDescription("Number of messages received from Redis."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.processed, err = meter.Int64Counter(
+		"redisdb.jobs.processed",
+		metric.WithDescription("Number of jobs processed by the run func, by status."),
+		metric.WithUnit("{job}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.duration, err = meter.Float64Histogram(
+		"redisdb.jobs.duration",
+		metric.WithDescription("Duration of the run func."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.expired, err = meter.Int64Counter(
+		"redisdb.locks.expired",
+		metric.WithDescription("Number of semaphore and lock leases that expired without being released."),
+		metric.WithUnit("{lease}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.publishMessages, err = meter.Int64Counter(
+		semconv.MessagingPublishMessagesName,
+		metric.WithDescription(semconv.MessagingPublishMessagesDescription),
+		metric.WithUnit(semconv.MessagingPublishMessagesUnit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.receiveMessages, err = meter.Int64Counter(
+		semconv.MessagingReceiveMessagesName,
+		metric.WithDescription(semconv.MessagingReceiveMessagesDescription),
+		metric.WithUnit(semconv.MessagingReceiveMessagesUnit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.processDuration, err = meter.Float64Histogram(
+		semconv.MessagingProcessDurationName,
+		metric.WithDescription(semconv.MessagingProcessDurationDescription),
+		metric.WithUnit(semconv.MessagingProcessDurationUnit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.acked, err = meter.Int64Counter(
+		"redisdb.messages.acked",
+		metric.WithDescription("Number of messages acknowledged."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.nacked, err = meter.Int64Counter(
+		"redisdb.messages.nacked",
+		metric.WithDescription("Number of messages rejected, to the dead letter queue or for redelivery."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.redelivered, err = meter.Int64Counter(
+		"redisdb.messages.redelivered",
+		metric.WithDescription("Number of messages handed back to Redis to be delivered again."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.latency, err = meter.Float64Histogram(
+		"redisdb.messages.latency",
+		metric.WithDescription("Time from when a job was queued until it started to run."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// observeDeadLetters reports the size and oldest age of the dead letter
+// queues read by stats on every collection.
+func (i *instruments) observeDeadLetters(stats func(context.Context) ([]DeadLetterStats, error)) error {
+	size, err := i.meter.Int64ObservableGauge(
+		"redisdb.deadletter.size",
+		metric.WithDescription("Number of messages in the dead letter queue."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	age, err := i.meter.Float64ObservableGauge(
+		"redisdb.deadletter.oldest_age",
+		metric.WithDescription("Age of the oldest message in the dead letter queue."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		values, err := stats(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range values {
+			attrs := metric.WithAttributes(attribute.String("channel", s.Channel))
+			o.ObserveInt64(size, s.Size, attrs)
+			o.ObserveFloat64(age, s.OldestAge.Seconds(), attrs)
+		}
+		return nil
+	}, size, age)
+
+	return err
+}
+
+func channelAttr(channel string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("channel", channel))
+}
+
+// messagingAttr returns the semantic convention attributes of an operation
+// on channel.
+func messagingAttr(channel string, operation attribute.KeyValue, extra ...attribute.KeyValue) metric.MeasurementOption {
+	return metric.WithAttributes(append(messagingAttrs(channel), append(extra, operation)...)...)
+}
+
+func errorTypeAttr(err error) []attribute.KeyValue {
+	if err == nil {
+		return nil
+	}
+	return []attribute.KeyValue{semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err))}
+}
+
+func (i *instruments) recordPublished(ctx context.Context, channel string) {
+	i.published.Add(ctx, 1, channelAttr(channel))
+	i.publishMessages.Add(ctx, 1, messagingAttr(channel, semconv.MessagingOperationTypePublish))
+}
+
+func (i *instruments) recordReceived(ctx context.Context, channel string) {
+	i.received.Add(ctx, 1, channelAttr(channel))
+	i.receiveMessages.Add(ctx, 1, messagingAttr(channel, semconv.MessagingOperationTypeReceive))
+}
+
+func (i *instruments) recordSettled(ctx context.Context, channel string, ack bool) {
+	if ack {
+		i.acked.Add(ctx, 1, channelAttr(channel))
+		return
+	}
+	i.nacked.Add(ctx, 1, channelAttr(channel))
+}
+
+func (i *instruments) recordRedelivered(ctx context.Context, channel string) {
+	i.redelivered.Add(ctx, 1, channelAttr(channel))
+}
+
+// recordLatency records the time since the job was queued. Jobs queued
+// without envelope are ignored.
+func (i *instruments) recordLatency(ctx context.Context, channel string, enqueuedAt time.Time) {
+	if enqueuedAt.IsZero() {
+		return
+	}
+	i.latency.Record(ctx, time.Since(enqueuedAt).Seconds(), channelAttr(channel))
+}
+
+func (i *instruments) recordLocksExpired(ctx context.Context, key string, n int64) {
+	i.expired.Add(ctx, n, metric.WithAttributes(attribute.String("lock", key)))
+}
+
+func (i *instruments) recordProcessed(ctx context.Context, channel string, start time.Time, err error) {
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	i.processed.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("channel", channel),
+		attribute.String("status", status),
+	))
+	i.duration.Record(ctx, time.Since(start).Seconds(), channelAttr(channel))
+	i.processDuration.Record(ctx, time.Since(start).Seconds(),
+		messagingAttr(channel, semconv.MessagingOperationTypeDeliver, errorTypeAttr(err)...))
+}
@@ -0,0 +1,130 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collectSums(t *testing.T, reader sdkmetric.Reader) map[string]int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	sums := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if data, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, dp := range data.DataPoints {
+					sums[m.Name] += dp.Value
+				}
+			}
+		}
+	}
+	return sums
+}
+
+func TestMeterProvider(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	done := make(chan struct{}, 2)
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("metrics"),
+		WithMeterProvider(mp),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			done <- struct{}{}
+			return nil
+		}),
+	)
+	q, err := queue.NewQueue(
+		queue.WithWorker(w),
+		queue.WithWorkerCount(1),
+	)
+	assert.NoError(t, err)
+	q.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, q.Queue(mockMessage{Message: "foo"}))
+	assert.NoError(t, q.Queue(mockMessage{Message: "bar"}))
+	<-done
+	<-done
+	q.Release()
+
+	sums := collectSums(t, reader)
+	assert.Equal(t, int64(2), sums["redisdb.messages.published"])
+	assert.Equal(t, int64(2), sums["redisdb.messages.received"])
+	assert.Equal(t, int64(2), sums["redisdb.jobs.processed"])
+}
+
+func TestMessagingMetrics(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("messaging-metrics"),
+		WithDeliveryMode(List),
+		WithMeterProvider(mp),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			switch string(m.Payload()) {
+			case "fail":
+				return errors.New("failed")
+			case "nack":
+				return Retry{}
+			}
+			return nil
+		}),
+	)
+
+	for _, v := range []string{"ok", "fail", "nack"} {
+		m := job.NewMessage(mockMessage{Message: v})
+		require.NoError(t, w.Queue(&m))
+	}
+	for i := 0; i < 3; i++ {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		_ = w.Run(ctx, task)
+	}
+
+	sums := collectSums(t, reader)
+	assert.Equal(t, int64(3), sums["messaging.publish.messages"])
+	assert.Equal(t, int64(3), sums["messaging.receive.messages"])
+	assert.Equal(t, int64(1), sums["redisdb.messages.acked"])
+	assert.Equal(t, int64(2), sums["redisdb.messages.nacked"])
+	assert.Equal(t, int64(1), sums["redisdb.messages.redelivered"])
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	counts := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if data, ok := m.Data.(metricdata.Histogram[float64]); ok {
+				for _, dp := range data.DataPoints {
+					counts[m.Name] += dp.Count
+				}
+			}
+		}
+	}
+	assert.Equal(t, uint64(3), counts["messaging.process.duration"])
+	assert.Equal(t, uint64(3), counts["redisdb.messages.latency"])
+	assert.NoError(t, w.Shutdown())
+}
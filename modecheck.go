@@ -0,0 +1,85 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrChannelModeConflict is returned when a channel is already used with
+// another delivery mode. Mixing modes on one channel silently splits or
+// drops messages, since each mode stores them differently.
+var ErrChannelModeConflict = errors.New("redisdb: channel is used with another delivery mode")
+
+func modeKey(channel string) string {
+	return channel + ":mode"
+}
+
+// claimChannels records the delivery mode of the worker on each of its
+// channels, and fails when one of them is already used with another mode.
+// Pub/sub channels have no key of their own, so their mode is not recorded
+// but checked against the one recorded by the other workers. List and
+// stream channels used before modes were recorded are checked by the type
+// of their key.
+func (w *Worker) claimChannels(ctx context.Context) error {
+	mode := w.opts.mode.String()
+	for _, channel := range w.opts.channels {
+		var (
+			ok  bool
+			err error
+		)
+		if w.opts.mode != PubSub {
+			ok, err = w.rdb.SetNX(ctx, modeKey(channel), mode, 0).Result()
+			if err != nil {
+				return err
+			}
+		}
+
+		current := mode
+		if ok {
+			current, err = w.keyMode(ctx, channel)
+		} else {
+			current, err = w.rdb.Get(ctx, modeKey(channel)).Result()
+			if errors.Is(err, redis.Nil) {
+				current, err = mode, nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if current != mode {
+			if ok {
+				w.rdb.Del(ctx, modeKey(channel))
+			}
+			return fmt.Errorf("%w: %q is used in %s mode, not %s (delete %q to change it)",
+				ErrChannelModeConflict, channel, current, mode, modeKey(channel))
+		}
+	}
+	return nil
+}
+
+// keyMode guesses the delivery mode of a channel from its key, in the List
+// and Stream modes that store the messages in it. The keys of the other
+// modes may be written by other libraries, such as Celery producers
+// pushing onto a list, and are not checked.
+func (w *Worker) keyMode(ctx context.Context, channel string) (string, error) {
+	switch w.opts.mode {
+	case List, Stream:
+	default:
+		return w.opts.mode.String(), nil
+	}
+	typ, err := w.rdb.Type(ctx, channel).Result()
+	if err != nil {
+		return "", err
+	}
+	switch typ {
+	case "list":
+		return List.String(), nil
+	case "stream":
+		return Stream.String(), nil
+	default:
+		return w.opts.mode.String(), nil
+	}
+}
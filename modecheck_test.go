@@ -0,0 +1,68 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestChannelModeConflict(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("modes"),
+		WithDeliveryMode(Stream),
+	)
+	assert.Equal(t, "stream", w.Redis().Get(ctx, modeKey("modes")).Val())
+	// the same mode can be used by any number of workers
+	assert.NoError(t, w.claimChannels(ctx))
+
+	w.opts.mode = List
+	assert.ErrorIs(t, w.claimChannels(ctx), ErrChannelModeConflict)
+
+	// channels used before the mode was recorded
+	w.Redis().Del(ctx, modeKey("modes"))
+	assert.ErrorIs(t, w.claimChannels(ctx), ErrChannelModeConflict)
+	assert.Equal(t, int64(0), w.Redis().Exists(ctx, modeKey("modes")).Val())
+
+	w.opts.mode = Stream
+	assert.NoError(t, w.claimChannels(ctx))
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestChannelModeForeignQueue(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	// a queue filled by a celery producer before any worker started
+	rdb := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer rdb.Close()
+	rdb.LPush(ctx, "celery", `{"body":""}`)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("celery"),
+		WithDeliveryMode(Celery),
+	)
+	assert.Equal(t, "celery", rdb.Get(ctx, modeKey("celery")).Val())
+	assert.Equal(t, int64(1), rdb.LLen(ctx, "celery").Val())
+	assert.NoError(t, w.Shutdown())
+
+	// pub/sub channels are not recorded, only checked
+	p := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("events"),
+		WithDeliveryMode(PubSub),
+	)
+	assert.Equal(t, int64(0), rdb.Exists(ctx, modeKey("events")).Val())
+	rdb.Set(ctx, modeKey("events"), "list", 0)
+	assert.ErrorIs(t, p.claimChannels(ctx), ErrChannelModeConflict)
+	assert.NoError(t, p.Shutdown())
+}
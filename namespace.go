@@ -0,0 +1,61 @@
+package redisdb
+
+import "strings"
+
+// withNamespace prefixes the channels with the namespace, so that every
+// key derived from them is in the namespace too. The keys of the Asynq,
+// Sidekiq and Celery modes are set by those libraries and keep their
+// channel names.
+func (o *options) withNamespace() {
+	if o.namespace == "" {
+		return
+	}
+	switch o.mode {
+	case Asynq, Sidekiq, Celery:
+		return
+	}
+
+	channels := make([]string, 0, len(o.channels))
+	for _, channel := range o.channels {
+		channels = append(channels, o.key(channel))
+	}
+	o.channels = channels
+	patterns := make([]string, 0, len(o.channelPatterns))
+	for _, pattern := range o.channelPatterns {
+		patterns = append(patterns, o.key(pattern))
+	}
+	o.channelPatterns = patterns
+	if o.bulkLane.Channel != "" {
+		o.bulkLane.Channel = o.key(o.bulkLane.Channel)
+	}
+}
+
+// channelKey returns the key of the channel name in the namespace, see
+// withNamespace.
+func (o *options) channelKey(name string) string {
+	switch o.mode {
+	case Asynq, Sidekiq, Celery:
+		return name
+	}
+	return o.key(name)
+}
+
+// key returns the key of name in the namespace.
+func (o *options) key(name string) string {
+	if o.namespace == "" {
+		return name
+	}
+	return o.namespace + ":" + name
+}
+
+// channelName returns the name a channel was configured with, without
+// the namespace, the shard and the broadcast retry suffix.
+func (o *options) channelName(channel string) string {
+	if o.broadcast {
+		channel = broadcastChannel(channel, o.consumerName)
+	}
+	if o.namespace != "" {
+		channel = strings.TrimPrefix(channel, o.namespace+":")
+	}
+	return o.shardName(channel)
+}
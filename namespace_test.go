@@ -0,0 +1,58 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestNamespace(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var channels []string
+	newWorker := func(namespace string) *Worker {
+		return NewWorker(
+			WithAddr(endpoint),
+			WithChannel("orders"),
+			WithDeliveryMode(List),
+			WithNamespace(namespace),
+			WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+				channels = append(channels, ChannelFromContext(ctx))
+				return nil
+			}),
+		)
+	}
+	prod := newWorker("app:prod")
+	staging := newWorker("app:staging")
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	require.NoError(t, prod.Queue(&m))
+	assert.Equal(t, int64(1), prod.Redis().LLen(ctx, "app:prod:orders").Val())
+	assert.Zero(t, prod.Redis().Exists(ctx, "orders").Val())
+	assert.Equal(t, "list", prod.Redis().Get(ctx, "app:prod:orders:mode").Val())
+	assert.Equal(t, int64(1), prod.Redis().ZCard(ctx, "app:prod:redisdb:workers").Val())
+
+	// workers of other namespaces do not see the job
+	_, err := staging.Fetch(ctx, time.Second)
+	assert.ErrorIs(t, err, queue.ErrNoTaskInQueue)
+	workers, err := staging.ListWorkers(ctx)
+	require.NoError(t, err)
+	assert.Len(t, workers, 1)
+
+	task, err := prod.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, prod.Run(ctx, task))
+	assert.Equal(t, []string{"orders"}, channels)
+
+	assert.NoError(t, prod.Shutdown())
+	assert.NoError(t, staging.Shutdown())
+}
@@ -3,29 +3,100 @@ package redisdb
 import (
 	"context"
 	"crypto/tls"
+	"log/slog"
+	"time"
 
 	"github.com/golang-queue/queue"
 	"github.com/golang-queue/queue/core"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Option for queue system
 type Option func(*options)
 
+type deadLetterAlert struct {
+	maxSize int64
+	maxAge  time.Duration
+	fn      func(DeadLetterStats)
+}
+
 type options struct {
 	runFunc          func(context.Context, core.TaskMessage) error
 	logger           queue.Logger
+	slog             *slog.Logger
 	addr             string
 	db               int
 	connectionString string
 	username         string
 	password         string
-	channelName      string
+	channels         []string
+	channelStrategy  ChannelStrategy
+	channelPatterns  []string
+	consumerGroup    string
+	consumerName     string
+	deadLetterAlert  deadLetterAlert
+	rateLimit        rateLimit
+	maxConcurrency   int
+	strictOrder      bool
+	idempotency      idempotency
+	backoff          Backoff
+	drain            bool
+	bulkLane         BulkLane
+	shutdownTimeout  time.Duration
+	ackPolicy        AckPolicy
+	jobType          func(core.TaskMessage) string
+	typeConcurrency  map[string]int
+	contextKeys      []contextKey
 	channelSize      int
 	cluster          bool
 	sentinel         bool
 	masterName       string
 	tls              *tls.Config
 	debug            bool
+	meterProvider    metric.MeterProvider
+	metricsRegistry  prometheus.Registerer
+	tracerProvider   trace.TracerProvider
+	blockTime        time.Duration
+	mode             DeliveryMode
+	lifecycleHook    func(State)
+	hooks            Hooks
+	errorHandler     func(context.Context, core.QueuedMessage, error)
+	codec            Codec
+	namespace        string
+	// enqueueFlushInterval and enqueueBatchSize enable the enqueue batches.
+	enqueueFlushInterval time.Duration
+	enqueueBatchSize     int
+	bufferSize           int
+	shards               int
+	// ackFlushInterval and ackBatchSize enable the ack batches.
+	ackFlushInterval time.Duration
+	ackBatchSize     int
+	guarantee        DeliveryGuarantee
+	panicHandler     func(ctx context.Context, msg core.QueuedMessage, recovered any, stack []byte)
+	panicDeadLetter  bool
+	// stallTimeout and maxLag are the thresholds of Worker.Healthy.
+	stallTimeout time.Duration
+	maxLag       time.Duration
+	// keyspaceNotifications wakes idle consumers on keyspace events.
+	keyspaceNotifications bool
+	jsonPayloadSize       int
+	// managed restricts the worker to the commands of managed clusters,
+	// see WithAWSCluster.
+	managed bool
+	flavor  ServerFlavor
+	jobTTL  time.Duration
+	// maxQueueLen bounds the waiting messages of a channel.
+	maxQueueLen int64
+	// blockWhenFull is how long producers wait for a full channel.
+	blockWhenFull time.Duration
+	jitter        Jitter
+	retryDelay    func(attempt int, err error, msg core.QueuedMessage) time.Duration
+	broadcast     bool
+	pubsubBacklog bool
 }
 
 // WithAddr setup the addr of redis
@@ -119,10 +190,200 @@ func WithConnectionString(connectionString string) Option {
 	}
 }
 
-// WithChannel setup the channel of redis
-func WithChannel(channel string) Option {
+// WithChannel setup the channels of redis. Queue sends messages to the first
+// channel and the worker consumes all of them, see WithChannelStrategy.
+func WithChannel(channels ...string) Option {
+	return func(w *options) {
+		if len(channels) == 0 {
+			return
+		}
+		w.channels = channels
+	}
+}
+
+// WithChannelPattern subscribe to every channel matching the glob patterns
+// instead of the channels set by WithChannel. Only applies to the PubSub
+// delivery mode. Use ChannelFromContext to get the channel of a message.
+func WithChannelPattern(patterns ...string) Option {
+	return func(w *options) {
+		w.channelPatterns = patterns
+	}
+}
+
+// WithConsumerGroup set the consumer group used in the Stream delivery mode.
+// Workers sharing a group split the messages between them, every group
+// receives all the messages of the stream.
+func WithConsumerGroup(name string) Option {
 	return func(w *options) {
-		w.channelName = channel
+		w.consumerGroup = name
+	}
+}
+
+// WithConsumerName set the name identifying the worker in its consumer group
+// and in list processing keys, defaults to hostname-pid. Names must be unique
+// among the running workers of a channel.
+func WithConsumerName(name string) Option {
+	return func(w *options) {
+		w.consumerName = name
+	}
+}
+
+// WithDeadLetterAlert call fn every minute for each channel whose dead letter queue
+// holds more than maxSize messages or whose oldest message is older than maxAge.
+// A zero threshold is not checked.
+func WithDeadLetterAlert(maxSize int64, maxAge time.Duration, fn func(DeadLetterStats)) Option {
+	return func(w *options) {
+		w.deadLetterAlert = deadLetterAlert{
+			maxSize: maxSize,
+			maxAge:  maxAge,
+			fn:      fn,
+		}
+	}
+}
+
+// WithRateLimit allow at most n jobs per duration to start on each channel.
+// The limit is a token bucket stored in Redis, so it is shared by all the
+// workers consuming the channel. It counts in milliseconds, per is rounded
+// up to the next one.
+func WithRateLimit(n int, per time.Duration) Option {
+	return func(w *options) {
+		if n <= 0 || per <= 0 {
+			return
+		}
+		// the bucket refills every per.Milliseconds(), never 0
+		if r := per % time.Millisecond; r != 0 {
+			per += time.Millisecond - r
+		}
+		w.rateLimit = rateLimit{n: n, per: per}
+	}
+}
+
+// WithMaxConcurrency allow at most n jobs of each channel to run at the same
+// time across all the workers consuming it, independently of the number of
+// queue workers. Slots are held in Redis and leased until the job timeout.
+func WithMaxConcurrency(n int) Option {
+	return func(w *options) {
+		w.maxConcurrency = n
+	}
+}
+
+// WithStrictOrder process the messages of each channel one at a time, in
+// the order they were queued, across all the workers consuming it. A worker
+// holds a lock on its channels in Redis from the moment it takes a message
+// until the message is settled. It does not apply to the PubSub mode.
+func WithStrictOrder() Option {
+	return func(w *options) {
+		w.strictOrder = true
+	}
+}
+
+// WithIdempotencyKey skip the messages whose job was already processed
+// successfully in the last ttl, so that messages redelivered after a crash
+// are not run twice. key returns the ID of the job of a message, a nil key
+// uses the ID assigned when the job was queued. Messages with an empty ID
+// are always run.
+func WithIdempotencyKey(key func(core.TaskMessage) string, ttl time.Duration) Option {
+	return func(w *options) {
+		w.idempotency = idempotency{enabled: true, key: key, ttl: ttl}
+	}
+}
+
+// WithBackoff set the backoff used to retry failed jobs in ProcessN, to
+// wait for concurrency slots and ordering locks, and to reconnect after
+// Redis errors. Jobs retried by the queue follow their own retry settings.
+func WithBackoff(b Backoff) Option {
+	return func(w *options) {
+		w.backoff = b
+	}
+}
+
+// WithJobType set the func returning the type of the job of a message,
+// used by WithTypeConcurrency.
+func WithJobType(fn func(core.TaskMessage) string) Option {
+	return func(w *options) {
+		w.jobType = fn
+	}
+}
+
+// WithTypeConcurrency allow at most n jobs of the given type to run at the
+// same time across all workers, whatever their channel, so that expensive
+// jobs cannot take every worker. The type of a job is read by the func set
+// with WithJobType.
+func WithTypeConcurrency(jobType string, n int) Option {
+	return func(w *options) {
+		if w.typeConcurrency == nil {
+			w.typeConcurrency = make(map[string]int)
+		}
+		w.typeConcurrency[jobType] = n
+	}
+}
+
+// WithContextKey propagate the string value stored under key in the context
+// given to QueueContext, such as a tenant or user ID, to the context of the
+// handler of the message. The value travels in the message headers under
+// name.
+func WithContextKey(name string, key any) Option {
+	return func(w *options) {
+		w.contextKeys = append(w.contextKeys, contextKey{name: name, key: key})
+	}
+}
+
+// WithDrainOnShutdown make Shutdown wait until the messages already fetched
+// are processed and settled before closing the Redis client. By default
+// Shutdown closes the client right away. The messages of jobs still running
+// are only delivered again to the running workers with
+// WithDeliveryGuarantee(AtLeastOnce); otherwise list messages wait for the
+// next worker to start and stream entries stay pending.
+func WithDrainOnShutdown() Option {
+	return func(w *options) {
+		w.drain = true
+	}
+}
+
+// WithShutdownTimeout make Shutdown wait up to d for the messages already
+// fetched to be processed and settled before closing the Redis client.
+// Messages still unsettled then are handled as without
+// WithDrainOnShutdown, whose wait it bounds: they are only delivered again
+// to the running workers with WithDeliveryGuarantee(AtLeastOnce).
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(w *options) {
+		w.shutdownTimeout = d
+	}
+}
+
+// WithAckPolicy set when the deliveries of jobs are acknowledged, after
+// they succeed by default.
+func WithAckPolicy(policy AckPolicy) Option {
+	return func(w *options) {
+		w.ackPolicy = policy
+	}
+}
+
+// WithDeliveryGuarantee makes the delivery semantics explicit. AtLeastOnce
+// acknowledges jobs once they succeed and reclaims the jobs of crashed
+// consumers while the workers run, with the Stream mode unless List is
+// set. AtMostOnce acknowledges jobs when they are received, see
+// AckBeforeRun, and is the behavior of the PubSub mode.
+func WithDeliveryGuarantee(g DeliveryGuarantee) Option {
+	return func(w *options) {
+		w.guarantee = g
+	}
+}
+
+// WithBulkLane queue the messages larger than the lane threshold to the
+// lane channel, which the worker consumes after its other channels.
+func WithBulkLane(lane BulkLane) Option {
+	return func(w *options) {
+		w.bulkLane = lane
+	}
+}
+
+// WithChannelStrategy set the order in which list and stream workers read
+// their channels: Priority (default) or RoundRobin. Pub/sub messages are
+// pushed by Redis as they are published, so the strategy does not apply.
+func WithChannelStrategy(strategy ChannelStrategy) Option {
+	return func(w *options) {
+		w.channelStrategy = strategy
 	}
 }
 
@@ -140,6 +401,16 @@ func WithLogger(l queue.Logger) Option {
 	}
 }
 
+// WithSlog set a structured logger for the worker, used in place of the
+// logger set by WithLogger. Records carry fields such as the channel, job
+// ID, attempt and duration of jobs.
+func WithSlog(l *slog.Logger) Option {
+	return func(w *options) {
+		w.slog = l
+		w.logger = slogLogger{l: l}
+	}
+}
+
 // WithDebug set debug mode
 func WithDebug() Option {
 	return func(w *options) {
@@ -147,12 +418,308 @@ func WithDebug() Option {
 	}
 }
 
+// WithMeterProvider set the OpenTelemetry meter provider used to record metrics
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(w *options) {
+		w.meterProvider = mp
+	}
+}
+
+// WithTracerProvider set the OpenTelemetry tracer provider used to trace
+// the jobs. Queue starts a producer span and stores its trace context with
+// the job, Run starts a consumer span around the run func as its child.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(w *options) {
+		w.tracerProvider = tp
+	}
+}
+
+// WithMetricsRegistry register Prometheus metrics on reg: the pending,
+// processing and dead-lettered messages of each channel, and the processed,
+// failed and retried jobs with the duration of the run func.
+func WithMetricsRegistry(reg prometheus.Registerer) Option {
+	return func(w *options) {
+		w.metricsRegistry = reg
+	}
+}
+
+// WithBlockTime set how long Request waits for a new message before
+// returning queue.ErrNoTaskInQueue. Shutdown always interrupts the wait.
+func WithBlockTime(d time.Duration) Option {
+	return func(w *options) {
+		if d <= 0 {
+			return
+		}
+		w.blockTime = d
+	}
+}
+
+// WithDeliveryMode set how messages are carried through Redis: PubSub (default), List or Stream
+func WithDeliveryMode(mode DeliveryMode) Option {
+	return func(w *options) {
+		w.mode = mode
+	}
+}
+
+// WithLifecycleHook set a callback for the READY, DRAINING and STOPPED lifecycle states,
+// see SystemdNotify for a hook that reports them to systemd
+func WithLifecycleHook(fn func(State)) Option {
+	return func(w *options) {
+		w.lifecycleHook = fn
+	}
+}
+
+// WithHooks set callbacks called when jobs start, succeed, fail, are
+// retried or are dead-lettered, see Hooks.
+func WithHooks(hooks Hooks) Option {
+	return func(w *options) {
+		w.hooks = hooks
+	}
+}
+
+// WithErrorHandler set a func called with the message and the error every
+// time the run func returns an error or panics, to report failures in one
+// place. Panics are still raised to the queue after the handler returns,
+// unless WithPanicHandler or WithPanicDeadLetter is set.
+func WithErrorHandler(fn func(ctx context.Context, msg core.QueuedMessage, err error)) Option {
+	return func(w *options) {
+		w.errorHandler = fn
+	}
+}
+
+// WithPanicHandler set a func called with the message, the recovered value
+// and the stack trace when the run func panics. The panic is not raised to
+// the queue: Run returns a PanicError and the job is retried like a failed
+// one.
+func WithPanicHandler(fn func(ctx context.Context, msg core.QueuedMessage, recovered any, stack []byte)) Option {
+	return func(w *options) {
+		w.panicHandler = fn
+	}
+}
+
+// WithPanicDeadLetter moves the jobs whose run func panics to the dead
+// letter queue right away instead of retrying them. Run returns a
+// PanicError, see WithPanicHandler.
+func WithPanicDeadLetter() Option {
+	return func(w *options) {
+		w.panicDeadLetter = true
+	}
+}
+
+// WithNamespace prefixes every key of the worker with namespace and a
+// colon: channels and the keys derived from them, the worker registry, the
+// channel catalog and the job type semaphores. Applications and
+// environments sharing a Redis server then do not see each other's jobs.
+// Handlers and hooks get the channel names without the namespace, metrics,
+// logs and the admin APIs report the keys.
+func WithNamespace(namespace string) Option {
+	return func(w *options) {
+		w.namespace = namespace
+	}
+}
+
+// WithEnqueueFlushInterval coalesces the messages queued within d into one
+// pipelined write, to cut the round trips of bursty producers. Queue
+// returns once the batch of its message is written. Batches are written
+// early when they reach the size set by WithEnqueueBatchSize, 100 by
+// default.
+func WithEnqueueFlushInterval(d time.Duration) Option {
+	return func(w *options) {
+		w.enqueueFlushInterval = d
+	}
+}
+
+// WithEnqueueBatchSize set how many queued messages are written in one
+// pipeline, see WithEnqueueFlushInterval. The flush interval defaults to
+// 1ms when only the batch size is set.
+func WithEnqueueBatchSize(n int) Option {
+	return func(w *options) {
+		w.enqueueBatchSize = n
+	}
+}
+
+// WithBufferSize reads up to n messages ahead of the handlers into an
+// in-memory buffer, so fetching a job does not wait for a round trip to
+// Redis. Buffered messages count as in flight and are handed back to
+// their channel on Shutdown. It is ignored with WithStrictOrder.
+func WithBufferSize(n int) Option {
+	return func(w *options) {
+		w.bufferSize = n
+	}
+}
+
+// WithShards spreads each channel over n keys in different cluster slots,
+// so a busy channel is not bound to one node of a Redis Cluster. Jobs are
+// queued round-robin to the shards of the first channel, or by the hash of
+// their ShardKeyHeader header, and workers read all the shards. Shards
+// hold their own hash tag, do not put one in the channel names. Only the
+// List and Stream modes are sharded, and the order of the jobs is only
+// kept within a shard.
+func WithShards(n int) Option {
+	return func(w *options) {
+		w.shards = n
+	}
+}
+
+// WithAckFlushInterval gathers the acks of stream entries handled within d
+// and sends them in one XACK per stream, to cut the commands of busy
+// consumers. Batches are sent early when they reach the size set by
+// WithAckBatchSize, 100 by default, and on Shutdown. The entries whose ack
+// was not sent yet when a worker dies are delivered again. It only applies
+// to the Stream mode.
+func WithAckFlushInterval(d time.Duration) Option {
+	return func(w *options) {
+		w.ackFlushInterval = d
+	}
+}
+
+// WithAckBatchSize set how many acks are sent at once, see
+// WithAckFlushInterval. The flush interval defaults to 10ms when only the
+// batch size is set.
+func WithAckBatchSize(n int) Option {
+	return func(w *options) {
+		w.ackBatchSize = n
+	}
+}
+
+// WithCodec set the codec of the jobs stored in Redis, JSONCodec by
+// default. MsgpackCodec avoids encoding binary payloads as base64.
+func WithCodec(c Codec) Option {
+	return func(w *options) {
+		w.codec = c
+	}
+}
+
+// WithHealthCheck set the thresholds of Worker.Healthy: the consumer is
+// stalled when it fetched and finished no job for stallTimeout, 5 minutes
+// by default, and lagging when a pending job was queued more than maxLag
+// ago. A zero maxLag disables the lag check.
+func WithHealthCheck(stallTimeout, maxLag time.Duration) Option {
+	return func(w *options) {
+		if stallTimeout > 0 {
+			w.stallTimeout = stallTimeout
+		}
+		w.maxLag = maxLag
+	}
+}
+
+// WithKeyspaceNotifications wakes idle list workers as soon as a message
+// is pushed onto one of their channels, and moves delayed messages when
+// they are due, from the keyspace notifications of Redis instead of
+// polling. The server must publish them, with notify-keyspace-events set
+// to Klz or more. Workers still poll every second, as notifications sent
+// while reconnecting are lost. It is not supported on Redis Cluster.
+func WithKeyspaceNotifications() Option {
+	return func(w *options) {
+		w.keyspaceNotifications = true
+	}
+}
+
+// WithJSONPayloads stores the JSON payloads of at least minSize bytes as
+// RedisJSON documents, referenced from the queued message, so the queues
+// stay small and the job fields can be read on the server, see
+// Inspector.PayloadField. It needs the RedisJSON module of Redis Stack.
+// Documents are deleted once their job succeeds and expire after 7 days.
+// Only the List and Stream modes store payloads, and all the producers and
+// workers of a channel must run a release supporting them.
+func WithJSONPayloads(minSize int) Option {
+	return func(w *options) {
+		w.jsonPayloadSize = minSize
+	}
+}
+
+// WithServerFlavor set the server implementing the Redis protocol, so the
+// worker avoids the features it lacks: Redis Functions are only used on
+// FlavorRedis, keyspace notifications are not used on FlavorDragonfly, and
+// idle pending stream entries are filtered by the worker. The default is
+// FlavorRedis.
+func WithServerFlavor(f ServerFlavor) Option {
+	return func(w *options) {
+		w.flavor = f
+	}
+}
+
+// WithJobTTL expires the jobs queued by the worker that have not run d
+// after they were queued. Workers move expired jobs to the
+// <channel>:expired list, which keeps the last 1000, instead of running
+// them. Jobs retried later keep the deadline of their first run.
+func WithJobTTL(d time.Duration) Option {
+	return func(w *options) {
+		w.jobTTL = d
+	}
+}
+
+// WithMaxQueueLen refuses the jobs queued onto a channel where n messages
+// already wait, with ErrQueueFull, so the memory of Redis does not grow
+// unbounded when consumers fall behind. The length is checked and the
+// message pushed in one script. Only the List and Stream modes are
+// bounded, streams on Redis 7 or later.
+func WithMaxQueueLen(n int64) Option {
+	return func(w *options) {
+		w.maxQueueLen = n
+	}
+}
+
+// WithBlockWhenFull makes Queue wait for up to maxWait, or until its
+// context is done, when the channel holds the maximum number of messages
+// of WithMaxQueueLen, instead of returning ErrQueueFull right away. The
+// push is tried again every 100ms, giving backpressure to batch producers.
+func WithBlockWhenFull(maxWait time.Duration) Option {
+	return func(w *options) {
+		w.blockWhenFull = maxWait
+	}
+}
+
+// WithRetryJitter randomizes the delays between the retries of the failed
+// jobs, so jobs that failed together during an outage are not all retried
+// at the same time. It applies to the retries of ProcessN and DrainOnce,
+// where decorrelated delays are bounded by the RetryMax of the job, and to
+// the delays of WithRetryDelayFunc in Run, where DecorrelatedJitter acts as
+// FullJitter. The other retries of Run are paced by the queue. The default
+// is NoJitter.
+func WithRetryJitter(j Jitter) Option {
+	return func(w *options) {
+		w.jitter = j
+	}
+}
+
+// WithRetryDelayFunc set the func returning how long to wait before a
+// failed job is retried, for instance from the class of its error or the
+// Retry-After of an API. attempt is the run that failed, starting at 1.
+// The job is delivered again after the delay, like a job returning Retry,
+// as long as it has retries left. A negative delay leaves the retry to the
+// job retry settings.
+func WithRetryDelayFunc(fn func(attempt int, err error, msg core.QueuedMessage) time.Duration) Option {
+	return func(w *options) {
+		w.retryDelay = fn
+	}
+}
+
+// WithPubSubBacklog keeps the messages published in PubSub mode in the
+// <channel>:backlog sorted set, by publish time, until a worker handled
+// them, so the messages published while the workers restart are not lost:
+// a starting worker runs the messages left in the backlogs of its channels
+// up to the last one present, before the published ones. A message may then run twice, as when a worker stops
+// while running it. Producers must be set up with WithPubSubBacklog too.
+// Backlogs keep the last 10000 messages, and channel patterns have none.
+func WithPubSubBacklog() Option {
+	return func(w *options) {
+		w.pubsubBacklog = true
+	}
+}
+
 func newOptions(opts ...Option) options {
 	defaultOpts := options{
-		channelName: "queue",
+		channels: []string{"queue"},
 		// default channel size in go-redis package
-		channelSize: 100,
-		logger:      queue.NewLogger(),
+		channelSize:   100,
+		blockTime:     5 * time.Second,
+		stallTimeout:  defaultStallTimeout,
+		consumerGroup: defaultConsumerGroup,
+		consumerName:  defaultConsumerName(),
+		logger:        queue.NewLogger(),
+		meterProvider: noop.NewMeterProvider(),
+		codec:         JSONCodec{},
 		runFunc: func(context.Context, core.TaskMessage) error {
 			return nil
 		},
@@ -163,6 +730,11 @@ func newOptions(opts ...Option) options {
 		// Call the option giving the instantiated
 		opt(&defaultOpts)
 	}
+	defaultOpts.withGuarantee()
+	defaultOpts.withNamespace()
+	defaultOpts.withShards()
+	defaultOpts.withBulkLane()
+	defaultOpts.withBroadcast()
 
 	return defaultOpts
 }
@@ -0,0 +1,77 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/job"
+)
+
+// orderLockMargin is added to the lease of an ordering lock so that it
+// outlives the job it guards.
+const orderLockMargin = 5 * time.Second
+
+func orderKey(channel string) string {
+	return channel + ":ordering"
+}
+
+// orderLock is held across the worker fleet from the moment a message is
+// popped in strict order mode until it is settled, so that at most one
+// message of each channel is in flight.
+type orderLock struct {
+	sems   []*semaphore
+	tokens []string
+}
+
+// lockChannels takes the ordering locks of all the consumed channels,
+// waiting up to wait for them. The locks are taken in name order so that
+// workers consuming overlapping channels cannot deadlock.
+func (w *Worker) lockChannels(ctx context.Context, wait time.Duration) (*orderLock, error) {
+	channels := append([]string(nil), w.opts.channels...)
+	sort.Strings(channels)
+
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	l := &orderLock{}
+	for _, channel := range channels {
+		sem := w.newSemaphore(orderKey(channel), 1)
+		// cover the pop, the lease is extended once the job is known
+		token, err := sem.acquire(ctx, wait+orderLockMargin)
+		if err != nil {
+			l.release(context.Background())
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, queue.ErrNoTaskInQueue
+			}
+			return nil, err
+		}
+		l.sems = append(l.sems, sem)
+		l.tokens = append(l.tokens, token)
+	}
+	return l, nil
+}
+
+// extend holds the locks for as long as m may run, retries included.
+func (l *orderLock) extend(ctx context.Context, m *job.Message) error {
+	lease := semaphoreLease
+	if m.Timeout > 0 {
+		lease = m.Timeout*time.Duration(m.RetryCount+1) + m.RetryMax*time.Duration(m.RetryCount)
+	}
+	for i, sem := range l.sems {
+		if err := sem.extend(ctx, l.tokens[i], lease+orderLockMargin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *orderLock) release(ctx context.Context) error {
+	var errs []error
+	for i, sem := range l.sems {
+		errs = append(errs, sem.release(ctx, l.tokens[i]))
+	}
+	return errors.Join(errs...)
+}
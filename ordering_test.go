@@ -0,0 +1,78 @@
+package redisdb
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestStrictOrder(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var mu sync.Mutex
+	var rets []string
+	var running, peak int
+	runFunc := func(ctx context.Context, m core.TaskMessage) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		rets = append(rets, string(m.Payload()))
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}
+
+	var workers []*Worker
+	for i := 0; i < 3; i++ {
+		workers = append(workers, NewWorker(
+			WithAddr(endpoint),
+			WithChannel("fifo"),
+			WithDeliveryMode(Stream),
+			WithConsumerName("consumer-"+strconv.Itoa(i)),
+			WithStrictOrder(),
+			WithRunFunc(runFunc),
+		))
+	}
+
+	var expected []string
+	for i := 0; i < 9; i++ {
+		expected = append(expected, strconv.Itoa(i))
+		m := job.NewMessage(mockMessage{Message: strconv.Itoa(i)})
+		assert.NoError(t, workers[0].Queue(&m))
+	}
+
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+			for {
+				task, err := w.Fetch(ctx, 500*time.Millisecond)
+				if err != nil {
+					return
+				}
+				assert.NoError(t, w.Run(ctx, task))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, peak)
+	assert.Equal(t, expected, rets)
+	for _, w := range workers {
+		assert.NoError(t, w.Shutdown())
+	}
+}
@@ -0,0 +1,15 @@
+package redisdb
+
+import "fmt"
+
+// PanicError is returned by Run when the run func panics and a panic
+// handler or WithPanicDeadLetter is set.
+type PanicError struct {
+	// Value is the value the run func panicked with.
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("redisdb: panic: %v", e.Value)
+}
@@ -0,0 +1,55 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestPanicHandler(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var (
+		runs      int
+		recovered any
+		stack     string
+		payload   string
+	)
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("panics"),
+		WithDeliveryMode(List),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			runs++
+			panic("boom")
+		}),
+		WithPanicHandler(func(ctx context.Context, msg core.QueuedMessage, r any, s []byte) {
+			recovered, stack, payload = r, string(s), string(msg.(core.TaskMessage).Payload())
+		}),
+		WithPanicDeadLetter(),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{RetryCount: job.Int64(3)})
+	require.NoError(t, w.Queue(&m))
+	n, err := w.DrainOnce(ctx)
+	assert.Equal(t, 1, n)
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "boom", panicErr.Value)
+
+	// the job is reported with its stack and dead-lettered without retries
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, "boom", recovered)
+	assert.Equal(t, "foo", payload)
+	assert.Contains(t, stack, "panic_test.go")
+	assert.Equal(t, int64(1), w.Redis().XLen(ctx, deadKey("panics")).Val())
+	assert.NoError(t, w.Shutdown())
+}
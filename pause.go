@@ -0,0 +1,100 @@
+package redisdb
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// pausedKey is the set of the paused channels, shared by all the workers
+// of a namespace.
+const pausedKey = "redisdb:paused"
+
+// pausePollInterval is how often workers read the paused channels.
+const pausePollInterval = time.Second
+
+// pauseSet holds the channels a worker does not read.
+type pauseSet struct {
+	channels atomic.Pointer[map[string]bool]
+}
+
+func (p *pauseSet) has(channel string) bool {
+	if p == nil {
+		return false
+	}
+	m := p.channels.Load()
+	return m != nil && (*m)[channel]
+}
+
+func (p *pauseSet) empty() bool {
+	if p == nil {
+		return true
+	}
+	m := p.channels.Load()
+	return m == nil || len(*m) == 0
+}
+
+// loadPaused reads the paused channels among the consumed ones.
+func (w *Worker) loadPaused(ctx context.Context) error {
+	if w.opts.mode != List && w.opts.mode != Stream {
+		return nil
+	}
+	names, err := w.rdb.SMembers(ctx, w.opts.key(pausedKey)).Result()
+	if err != nil {
+		return err
+	}
+	paused := make(map[string]bool, len(names))
+	for _, name := range names {
+		paused[name] = true
+	}
+	w.paused.channels.Store(&paused)
+	return nil
+}
+
+func (w *Worker) watchPaused() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(pausePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.loadPaused(context.Background()); err != nil {
+				w.log.Error("failed to read paused channels", "error", err)
+			}
+		}
+	}
+}
+
+// Pause stops the workers from taking the jobs of the channels of the
+// inspector, within about a second. Jobs
+// can still be queued. Only the List and Stream modes can be paused.
+func (i *Inspector) Pause(ctx context.Context) error {
+	return i.w.rdb.SAdd(ctx, i.w.opts.key(pausedKey), i.w.opts.channels).Err()
+}
+
+// Resume lets the workers take the jobs of the channels of the inspector
+// again.
+func (i *Inspector) Resume(ctx context.Context) error {
+	return i.w.rdb.SRem(ctx, i.w.opts.key(pausedKey), i.w.opts.channels).Err()
+}
+
+// Paused returns the paused channels among the ones of the inspector.
+func (i *Inspector) Paused(ctx context.Context) ([]string, error) {
+	names, err := i.w.rdb.SMembers(ctx, i.w.opts.key(pausedKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+	paused := make(map[string]bool, len(names))
+	for _, name := range names {
+		paused[name] = true
+	}
+	var res []string
+	for _, channel := range i.w.opts.channels {
+		if paused[channel] {
+			res = append(res, channel)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,108 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestPause(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			channel := "pause-" + mode.String()
+			opts := []Option{
+				WithAddr(endpoint),
+				WithChannel(channel),
+				WithDeliveryMode(mode),
+			}
+			w := NewWorker(opts...)
+			i := NewInspector(opts...)
+			defer i.Close()
+
+			require.NoError(t, i.Pause(ctx))
+			paused, err := i.Paused(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []string{channel}, paused)
+			require.NoError(t, w.loadPaused(ctx))
+
+			m := job.NewMessage(mockMessage{Message: "foo"})
+			require.NoError(t, w.Queue(&m))
+			_, err = w.Fetch(ctx, 200*time.Millisecond)
+			assert.Error(t, err)
+
+			require.NoError(t, i.Resume(ctx))
+			require.NoError(t, w.loadPaused(ctx))
+			task, err := w.Fetch(ctx, time.Second)
+			require.NoError(t, err)
+			assert.NoError(t, w.Run(ctx, task))
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
+
+func TestRequeueDead(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			channel := "requeue-dead-" + mode.String()
+			rets := make(chan string, 2)
+			w := NewWorker(
+				WithAddr(endpoint),
+				WithChannel(channel),
+				WithDeliveryMode(mode),
+				WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+					rets <- string(m.Payload())
+					return nil
+				}),
+			)
+
+			for _, v := range []string{"a", "b"} {
+				m := job.NewMessage(mockMessage{Message: v})
+				require.NoError(t, w.Queue(&m))
+			}
+			var data [][]byte
+			var err error
+			if mode == Stream {
+				data, err = w.peekStream(ctx, channel, 2)
+			} else {
+				data, err = w.peekList(ctx, channel, 2)
+			}
+			require.NoError(t, err)
+			_, err = w.Purge(ctx)
+			require.NoError(t, err)
+			for _, d := range data {
+				require.NoError(t, w.Redis().XAdd(ctx, &redis.XAddArgs{
+					Stream: deadKey(channel),
+					Values: map[string]any{streamPayloadField: d},
+				}).Err())
+			}
+
+			n, err := w.RequeueDead(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			size, err := w.Redis().XLen(ctx, deadKey(channel)).Result()
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), size)
+
+			task, err := w.Fetch(ctx, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, w.Run(ctx, task))
+			assert.Equal(t, "a", <-rets)
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package redisdb
+
+import (
+	"context"
+	"slices"
+)
+
+// QueuedMessage is a message waiting in a channel, as returned by Peek.
+type QueuedMessage struct {
+	Channel string `json:"channel"`
+	// JobID is the ID assigned when the job was queued, see QueueWithID.
+	JobID string `json:"job_id,omitempty"`
+	// Payload is the body of the job, or the raw message when it cannot be
+	// decoded.
+	Payload []byte `json:"payload"`
+}
+
+// Peek returns up to n messages waiting in the consumed channels, in the
+// order the worker would read them with the Priority strategy, without
+// removing or claiming them. Messages already taken by a worker and delayed
+// messages are not returned, and pub/sub workers have no waiting messages.
+func (w *Worker) Peek(ctx context.Context, n int) ([]QueuedMessage, error) {
+	if w.opts.mode == PubSub || n <= 0 {
+		return nil, nil
+	}
+
+	var res []QueuedMessage
+	for _, channel := range w.opts.channels {
+		if len(res) >= n {
+			break
+		}
+		var (
+			data [][]byte
+			err  error
+		)
+		if w.opts.mode == Stream {
+			data, err = w.peekStream(ctx, channel, n-len(res))
+		} else {
+			data, err = w.peekList(ctx, channel, n-len(res))
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range data {
+			res = append(res, w.peeked(channel, d))
+		}
+	}
+
+	return res, nil
+}
+
+// peekList reads the tail of the list, where workers pop from.
+func (w *Worker) peekList(ctx context.Context, channel string, n int) ([][]byte, error) {
+	values, err := w.rdb.LRange(ctx, channel, int64(-n), -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(values)
+	data := make([][]byte, 0, len(values))
+	for _, v := range values {
+		data = append(data, []byte(v))
+	}
+	return data, nil
+}
+
+// peekStream reads the entries after the last one delivered to the consumer
+// group of the worker.
+func (w *Worker) peekStream(ctx context.Context, channel string, n int) ([][]byte, error) {
+	start := "-"
+	groups, err := w.rdb.XInfoGroups(ctx, channel).Result()
+	if isReplyError(err) {
+		// the stream does not exist yet
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if g.Name == w.opts.consumerGroup {
+			start = "(" + g.LastDeliveredID
+		}
+	}
+
+	entries, err := w.rdb.XRangeN(ctx, channel, start, "+", int64(n)).Result()
+	if err != nil {
+		return nil, err
+	}
+	data := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		payload, _ := e.Values[streamPayloadField].(string)
+		data = append(data, []byte(payload))
+	}
+	return data, nil
+}
+
+func (w *Worker) peeked(channel string, data []byte) QueuedMessage {
+	qm := QueuedMessage{Channel: channel, Payload: data}
+	if m, env, err := w.decode(data); err == nil {
+		qm.Payload = m.Body
+		qm.JobID = env.ID
+	}
+	return qm
+}
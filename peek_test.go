@@ -0,0 +1,59 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestPeek(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			channel := "peek-" + mode.String()
+			w := NewWorker(
+				WithAddr(endpoint),
+				WithChannel(channel),
+				WithDeliveryMode(mode),
+			)
+
+			var ids []string
+			for _, v := range []string{"a", "b", "c"} {
+				m := job.NewMessage(mockMessage{Message: v})
+				id, err := w.QueueWithID(ctx, &m)
+				require.NoError(t, err)
+				ids = append(ids, id)
+			}
+
+			task, err := w.Fetch(ctx, time.Second)
+			require.NoError(t, err)
+			assert.NoError(t, w.Run(ctx, task))
+
+			res, err := w.Peek(ctx, 5)
+			require.NoError(t, err)
+			require.Len(t, res, 2)
+			for i, v := range []string{"b", "c"} {
+				assert.Equal(t, v, string(res[i].Payload))
+				assert.Equal(t, ids[i+1], res[i].JobID)
+				assert.Equal(t, channel, res[i].Channel)
+			}
+
+			// peeking does not consume
+			res, err = w.Peek(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, res, 1)
+			task, err = w.Fetch(ctx, time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, "b", string(task.(*job.Message).Payload()))
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
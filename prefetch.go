@@ -0,0 +1,111 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-queue/queue"
+)
+
+// prefetchWait bounds the reads of the prefetch goroutine, so Shutdown
+// does not wait for a whole block time.
+const prefetchWait = time.Second
+
+// prefetcher keeps a bounded buffer of messages read ahead from Redis, so
+// Fetch hands them out without waiting for the network. Buffered messages
+// are already claimed, like the ones being run.
+type prefetcher struct {
+	w   *Worker
+	buf chan *delivery
+}
+
+func newPrefetcher(w *Worker) *prefetcher {
+	p := &prefetcher{
+		w:   w,
+		buf: make(chan *delivery, w.opts.bufferSize),
+	}
+	w.wg.Add(1)
+	go p.run()
+	return p
+}
+
+// run fills the buffer until the worker stops.
+func (p *prefetcher) run() {
+	defer p.w.wg.Done()
+	ctx := context.Background()
+	for {
+		select {
+		case <-p.w.stop:
+			return
+		default:
+		}
+
+		d, err := p.w.broker.pop(ctx, min(p.w.opts.blockTime, prefetchWait))
+		switch {
+		case errors.Is(err, queue.ErrNoTaskInQueue):
+			continue
+		case errors.Is(err, queue.ErrQueueHasBeenClosed):
+			return
+		case err != nil:
+			if atomic.LoadInt32(&p.w.stopFlag) == 0 {
+				p.w.log.Error("failed to prefetch messages", "error", err)
+			}
+			p.w.waitReconnect(ctx, prefetchWait)
+			continue
+		}
+		atomic.StoreInt32(&p.w.popFailures, 0)
+
+		select {
+		case p.buf <- d:
+		case <-p.w.stop:
+			p.requeue(d)
+			return
+		}
+	}
+}
+
+// pop returns the next buffered message, waiting for up to wait.
+func (p *prefetcher) pop(ctx context.Context, wait time.Duration) (*delivery, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case d := <-p.buf:
+		return d, nil
+	case <-p.w.stop:
+		return nil, queue.ErrQueueHasBeenClosed
+	case <-ctx.Done():
+		return nil, queue.ErrNoTaskInQueue
+	case <-timer.C:
+		return nil, queue.ErrNoTaskInQueue
+	}
+}
+
+// requeue hands a buffered message back to its channel.
+func (p *prefetcher) requeue(d *delivery) {
+	if err := p.w.broker.requeue(context.Background(), d, d.data); err != nil {
+		p.w.log.Error("failed to requeue prefetched message", "channel", d.channel, "error", err)
+	}
+}
+
+// close requeues the messages left in the buffer, once the prefetch
+// goroutine stopped.
+func (p *prefetcher) close() {
+	for {
+		select {
+		case d := <-p.buf:
+			p.requeue(d)
+		default:
+			return
+		}
+	}
+}
+
+// pop reads the next message, from the prefetch buffer when it is enabled.
+func (w *Worker) pop(ctx context.Context, wait time.Duration) (*delivery, error) {
+	if w.prefetcher != nil {
+		return w.prefetcher.pop(ctx, wait)
+	}
+	return w.broker.pop(ctx, wait)
+}
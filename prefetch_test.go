@@ -0,0 +1,53 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestPrefetch(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	producer := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("prefetched"),
+		WithDeliveryMode(List),
+	)
+	rdb := producer.Redis()
+	for i := 0; i < 5; i++ {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		require.NoError(t, producer.Queue(&m))
+	}
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("prefetched"),
+		WithDeliveryMode(List),
+		WithConsumerName("prefetcher"),
+		WithBufferSize(2),
+	)
+	// the buffer is filled, plus the message waiting for room
+	assert.Eventually(t, func() bool {
+		return rdb.LLen(ctx, "prefetched").Val() == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(3), rdb.LLen(ctx, processingKey("prefetched", "prefetcher")).Val())
+
+	msg, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(msg.Payload()))
+	assert.NoError(t, w.Run(ctx, msg))
+
+	// the buffered messages go back to the queue on shutdown
+	assert.NoError(t, w.Shutdown())
+	assert.Equal(t, int64(4), rdb.LLen(ctx, "prefetched").Val())
+	assert.Zero(t, rdb.LLen(ctx, processingKey("prefetched", "prefetcher")).Val())
+	assert.NoError(t, producer.Shutdown())
+}
@@ -0,0 +1,146 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// promMetrics holds the Prometheus collectors registered by
+// WithMetricsRegistry. A nil *promMetrics records nothing.
+type promMetrics struct {
+	processed *prometheus.CounterVec
+	failed    *prometheus.CounterVec
+	retried   *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+}
+
+func newPromMetrics(w *Worker, reg prometheus.Registerer) (*promMetrics, error) {
+	p := &promMetrics{
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redisdb_jobs_processed_total",
+			Help: "Number of jobs processed by the run func.",
+		}, []string{"channel"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redisdb_jobs_failed_total",
+			Help: "Number of job attempts that returned an error.",
+		}, []string{"channel"}),
+		retried: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redisdb_jobs_retried_total",
+			Help: "Number of failed job attempts that will be retried.",
+		}, []string{"channel"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "redisdb_job_duration_seconds",
+			Help:    "Duration of the run func.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"channel"}),
+	}
+
+	// workers sharing a registry share the counters
+	var err error
+	if p.processed, err = registerOrReuse(reg, p.processed); err != nil {
+		return nil, err
+	}
+	if p.failed, err = registerOrReuse(reg, p.failed); err != nil {
+		return nil, err
+	}
+	if p.retried, err = registerOrReuse(reg, p.retried); err != nil {
+		return nil, err
+	}
+	if p.duration, err = registerOrReuse(reg, p.duration); err != nil {
+		return nil, err
+	}
+
+	if w.opts.mode != PubSub {
+		if err := reg.Register(newDepthCollector(w)); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// registerOrReuse registers c, or returns the collector already registered
+// with the same descriptors.
+func registerOrReuse[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return c, err
+}
+
+func (p *promMetrics) recordProcessed(channel string, start time.Time, err error, retry bool) {
+	if p == nil {
+		return
+	}
+	p.processed.WithLabelValues(channel).Inc()
+	p.duration.WithLabelValues(channel).Observe(time.Since(start).Seconds())
+	if err != nil {
+		p.failed.WithLabelValues(channel).Inc()
+	}
+	if retry {
+		p.retried.WithLabelValues(channel).Inc()
+	}
+}
+
+// depthCollector reads the number of pending, processing and dead-lettered
+// messages of the consumed channels on every scrape.
+type depthCollector struct {
+	w          *Worker
+	pending    *prometheus.Desc
+	processing *prometheus.Desc
+	dead       *prometheus.Desc
+}
+
+func newDepthCollector(w *Worker) *depthCollector {
+	return &depthCollector{
+		w: w,
+		pending: prometheus.NewDesc("redisdb_messages_pending",
+			"Number of messages waiting to be taken by a worker.", []string{"channel"}, nil),
+		processing: prometheus.NewDesc("redisdb_messages_processing",
+			"Number of messages taken by workers and not settled yet.", []string{"channel"}, nil),
+		dead: prometheus.NewDesc("redisdb_messages_dead",
+			"Number of messages in the dead letter queue.", []string{"channel"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *depthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pending
+	ch <- c.processing
+	ch <- c.dead
+}
+
+// Collect implements prometheus.Collector.
+func (c *depthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	for _, channel := range c.w.opts.channels {
+		var (
+			waiting, inflight int64
+			err               error
+		)
+		if c.w.opts.mode == Stream {
+			waiting, inflight, err = c.w.streamDepth(ctx, channel)
+		} else {
+			waiting, inflight, err = c.w.listDepth(ctx, channel)
+		}
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.pending, err)
+			continue
+		}
+		dead, err := c.w.rdb.XLen(ctx, deadKey(channel)).Result()
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.dead, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(waiting), channel)
+		ch <- prometheus.MustNewConstMetric(c.processing, prometheus.GaugeValue, float64(inflight), channel)
+		ch <- prometheus.MustNewConstMetric(c.dead, prometheus.GaugeValue, float64(dead), channel)
+	}
+}
@@ -0,0 +1,76 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func gatherValues(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			switch {
+			case m.GetCounter() != nil:
+				values[f.GetName()] += m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				values[f.GetName()] += m.GetGauge().GetValue()
+			case m.GetHistogram() != nil:
+				values[f.GetName()] += float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return values
+}
+
+func TestMetricsRegistry(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	reg := prometheus.NewRegistry()
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("prometheus"),
+		WithDeliveryMode(List),
+		WithMetricsRegistry(reg),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			if string(m.Payload()) == "fail" {
+				return errors.New("failed")
+			}
+			return nil
+		}),
+	)
+
+	for _, v := range []string{"ok", "fail", "waiting"} {
+		m := job.NewMessage(mockMessage{Message: v})
+		require.NoError(t, w.Queue(&m))
+	}
+	for i := 0; i < 2; i++ {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		_ = w.Run(ctx, task)
+	}
+
+	values := gatherValues(t, reg)
+	assert.Equal(t, float64(2), values["redisdb_jobs_processed_total"])
+	assert.Equal(t, float64(1), values["redisdb_jobs_failed_total"])
+	assert.Equal(t, float64(0), values["redisdb_jobs_retried_total"])
+	assert.Equal(t, float64(2), values["redisdb_job_duration_seconds"])
+	assert.Equal(t, float64(1), values["redisdb_messages_pending"])
+	assert.Equal(t, float64(0), values["redisdb_messages_processing"])
+	assert.Equal(t, float64(1), values["redisdb_messages_dead"])
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,78 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang-queue/queue"
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Publish queues a copy of a job onto every given channel, for events that
+// several independent consumers must process, and returns the ID shared by
+// the copies. The channels get the namespace of the worker. The copy queued
+// onto the channel of the worker goes through its shards and bulk lane like
+// Queue does, the other channels are not sharded since their layout is
+// their consumers' own. The copies are queued in one transaction, which is
+// only atomic on a single Redis server: on a cluster, the channels in other
+// slots are written separately. Published jobs are not bounded by
+// WithMaxQueueLen, nor stored as RedisJSON documents.
+func (w *Worker) Publish(ctx context.Context, task core.TaskMessage, channels ...string) (string, error) {
+	if atomic.LoadInt32(&w.stopFlag) == 1 {
+		return "", queue.ErrQueueShutdown
+	}
+	if len(channels) == 0 {
+		return "", errors.New("redisdb: no channel to publish to")
+	}
+	m, err := toMessage(task)
+	if err != nil {
+		return "", err
+	}
+
+	env := w.newEnvelope(ctx, ulid.Make().String())
+	own := w.opts.channelName(w.opts.channels[0])
+	keys := make([]string, len(channels))
+	msgs := make([]*job.Message, len(channels))
+	for i, channel := range channels {
+		if channel == own {
+			if keys[i], msgs[i], err = w.route(m, env); err != nil {
+				return "", err
+			}
+			continue
+		}
+		keys[i], msgs[i] = w.opts.channelKey(channel), m
+	}
+
+	ctx, span, carrier := w.startPublishSpan(ctx, strings.Join(keys, ","), env.ID)
+	env.Trace = carrier
+	data := make([][]byte, len(channels))
+	for i := range msgs {
+		if data[i], err = w.encode(msgs[i], env); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		_, err = w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, channel := range keys {
+				if err := w.broker.push(ctx, pipe, channel, data[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	endSpan(span, err)
+	if err != nil {
+		return "", err
+	}
+	for _, channel := range keys {
+		w.metrics.recordPublished(ctx, channel)
+		w.throughput.recordQueued(channel)
+	}
+	return env.ID, nil
+}
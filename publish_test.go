@@ -0,0 +1,91 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("billing"),
+		WithDeliveryMode(Stream),
+	)
+	defer w.Shutdown()
+	audit := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("audit"),
+		WithDeliveryMode(Stream),
+	)
+	defer audit.Shutdown()
+
+	m := job.NewMessage(mockMessage{Message: "order placed"})
+	id, err := w.Publish(ctx, &m, "billing", "audit")
+	require.NoError(t, err)
+
+	for _, consumer := range []*Worker{w, audit} {
+		task, err := consumer.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "order placed", string(task.Payload()))
+		require.NoError(t, consumer.Run(ctx, task))
+	}
+
+	// the copies share the job ID
+	for _, channel := range []string{"billing", "audit"} {
+		entries := w.Redis().XRange(ctx, channel, "-", "+").Val()
+		require.Len(t, entries, 1)
+		data, _ := entries[0].Values[streamPayloadField].(string)
+		_, env, err := w.decode([]byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, id, env.ID)
+	}
+}
+
+func TestPublishShards(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("billing"),
+		WithDeliveryMode(List),
+		WithShards(2),
+		WithBulkLane(BulkLane{Channel: "billing-bulk", Threshold: 16}),
+	)
+	defer w.Shutdown()
+	audit := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("audit"),
+		WithDeliveryMode(List),
+	)
+	defer audit.Shutdown()
+
+	// the copies for the channel of the worker are queued onto its shards,
+	// or its bulk lane when large, the others onto the channel itself
+	small := job.NewMessage(mockMessage{Message: "paid"})
+	_, err := w.Publish(ctx, &small, "billing", "audit")
+	require.NoError(t, err)
+	large := job.NewMessage(mockMessage{Message: "invoice with many lines"})
+	_, err = w.Publish(ctx, &large, "billing", "audit")
+	require.NoError(t, err)
+	assert.Zero(t, w.Redis().Exists(ctx, "billing").Val())
+	assert.Equal(t, int64(1), w.Redis().LLen(ctx, "billing-bulk").Val())
+	assert.Equal(t, int64(2), w.Redis().LLen(ctx, "audit").Val())
+
+	for _, consumer := range []*Worker{w, w, audit, audit} {
+		task, err := consumer.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, consumer.Run(ctx, task))
+	}
+}
@@ -0,0 +1,253 @@
+package redisdb
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-queue/queue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ broker = (*pubsubBroker)(nil)
+
+// pubsubBacklogMaxLen caps the backlog of a channel, see WithPubSubBacklog.
+const pubsubBacklogMaxLen = 10000
+
+// backlogReadSize bounds the messages read at once from a backlog.
+const backlogReadSize = 10
+
+// backlogKey is the sorted set keeping the messages published on channel,
+// by publish time, until they are handled, see WithPubSubBacklog.
+func backlogKey(channel string) string {
+	return channel + ":backlog"
+}
+
+// backlogCursor is the position of a worker replaying a backlog: the score
+// and message last taken, and the ones of the last message published
+// before the worker started.
+type backlogCursor struct {
+	score   float64
+	data    string
+	end     float64
+	endData string
+}
+
+// pubsubBroker delivers messages with Redis Pub/Sub.
+type pubsubBroker struct {
+	rdb    redis.Cmdable
+	pubsub *redis.PubSub
+	recv   <-chan *redis.Message
+	stop   <-chan struct{}
+	// backlog keeps the published messages in lists until they are
+	// handled, see WithPubSubBacklog.
+	backlog bool
+
+	// missed holds the cursors of the backlogs replayed by the worker, the
+	// messages left there when it started are taken before the published
+	// ones.
+	mu     sync.Mutex
+	missed map[string]*backlogCursor
+}
+
+func newPubSubBroker(ctx context.Context, w *Worker) (*pubsubBroker, error) {
+	b := &pubsubBroker{
+		rdb:     w.rdb,
+		stop:    w.stop,
+		backlog: w.opts.pubsubBacklog,
+	}
+
+	// patterns replace the channel subscription, Queue still publishes
+	// to the first channel
+	switch v := w.rdb.(type) {
+	case *redis.Client:
+		if len(w.opts.channelPatterns) > 0 {
+			b.pubsub = v.PSubscribe(ctx, w.opts.channelPatterns...)
+		} else {
+			b.pubsub = v.Subscribe(ctx, w.opts.channels...)
+		}
+	case *redis.ClusterClient:
+		if len(w.opts.channelPatterns) > 0 {
+			b.pubsub = v.PSubscribe(ctx, w.opts.channelPatterns...)
+		} else {
+			b.pubsub = v.Subscribe(ctx, w.opts.channels...)
+		}
+	}
+
+	var ropts []redis.ChannelOption
+
+	if w.opts.channelSize > 1 {
+		ropts = append(ropts, redis.WithChannelSize(w.opts.channelSize))
+	}
+
+	b.recv = b.pubsub.Channel(ropts...)
+	// make sure the connection is successful
+	if err := b.pubsub.Ping(ctx); err != nil {
+		return nil, err
+	}
+
+	// the backlog is read once subscribed, so no message is missed
+	if b.backlog {
+		b.missed = make(map[string]*backlogCursor)
+		for _, channel := range w.opts.channels {
+			last, err := b.rdb.ZRangeWithScores(ctx, backlogKey(channel), -1, -1).Result()
+			if err != nil {
+				return nil, err
+			}
+			if len(last) > 0 {
+				b.missed[channel] = &backlogCursor{
+					score:   math.Inf(-1),
+					end:     last[0].Score,
+					endData: last[0].Member.(string),
+				}
+			}
+		}
+	}
+
+	return b, nil
+}
+
+func (b *pubsubBroker) push(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error {
+	if !b.backlog {
+		return rdb.Publish(ctx, channel, data).Err()
+	}
+	write := func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, backlogKey(channel), redis.Z{Score: float64(time.Now().UnixMilli()), Member: data})
+		pipe.ZRemRangeByRank(ctx, backlogKey(channel), 0, -pubsubBacklogMaxLen-1)
+		pipe.Publish(ctx, channel, data)
+		return nil
+	}
+	if pipe, ok := rdb.(redis.Pipeliner); ok {
+		return write(pipe)
+	}
+	_, err := rdb.TxPipelined(ctx, write)
+	return err
+}
+
+// requeue publishes the message again.
+func (b *pubsubBroker) requeue(ctx context.Context, d *delivery, data []byte) error {
+	if !b.backlog {
+		return b.rdb.Publish(ctx, d.channel, data).Err()
+	}
+	_, err := b.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, backlogKey(d.channel), d.data)
+		return b.push(ctx, pipe, d.channel, data)
+	})
+	return err
+}
+
+// takeMissed returns the next message left in a backlog when the worker
+// started, or nil. The message stays in the backlog until it is handled,
+// the cursor of the backlog moves past it. Messages published since the
+// worker started are received from the channel instead.
+func (b *pubsubBroker) takeMissed(ctx context.Context) (*delivery, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for channel, c := range b.missed {
+		data, err := b.next(ctx, channel, c)
+		if err != nil {
+			return nil, err
+		}
+		if data == "" {
+			delete(b.missed, channel)
+			continue
+		}
+		return &delivery{channel: channel, data: []byte(data)}, nil
+	}
+	return nil, nil
+}
+
+// next moves c to the message following it in the backlog of channel, and
+// returns it, or "" once past the end of c. Messages published in the same
+// millisecond are ordered by their data, like in the sorted set.
+func (b *pubsubBroker) next(ctx context.Context, channel string, c *backlogCursor) (string, error) {
+	for offset := int64(0); ; offset += backlogReadSize {
+		msgs, err := b.rdb.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{
+			Key:     backlogKey(channel),
+			Start:   formatScore(c.score),
+			Stop:    formatScore(c.end),
+			ByScore: true,
+			Offset:  offset,
+			Count:   backlogReadSize,
+		}).Result()
+		if err != nil || len(msgs) == 0 {
+			return "", err
+		}
+		for _, msg := range msgs {
+			data := msg.Member.(string)
+			if msg.Score == c.score && data <= c.data {
+				// taken already
+				continue
+			}
+			if msg.Score == c.end && data > c.endData {
+				// published since the worker started
+				return "", nil
+			}
+			c.score, c.data = msg.Score, data
+			return data, nil
+		}
+	}
+}
+
+func formatScore(score float64) string {
+	if math.IsInf(score, -1) {
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+func (b *pubsubBroker) touch(ctx context.Context, d *delivery) error {
+	return nil
+}
+
+func (b *pubsubBroker) pop(ctx context.Context, timeout time.Duration) (*delivery, error) {
+	if b.backlog {
+		d, err := b.takeMissed(ctx)
+		if d != nil || err != nil {
+			return d, err
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case msg, ok := <-b.recv:
+		if !ok {
+			return nil, queue.ErrQueueHasBeenClosed
+		}
+		return &delivery{
+			channel: msg.Channel,
+			data:    []byte(msg.Payload),
+		}, nil
+	case <-b.stop:
+		return nil, queue.ErrQueueHasBeenClosed
+	case <-ctx.Done():
+		return nil, queue.ErrNoTaskInQueue
+	case <-timer.C:
+		return nil, queue.ErrNoTaskInQueue
+	}
+}
+
+// ack removes a handled message from the backlog. Without backlog it is a
+// no-op: pub/sub messages are gone once received. Messages retried later
+// are acknowledged once scheduled, and pushed onto the backlog again when
+// they are due, see moveDelayedScript.
+func (b *pubsubBroker) ack(ctx context.Context, d *delivery) error {
+	if !b.backlog {
+		return nil
+	}
+	return b.rdb.ZRem(ctx, backlogKey(d.channel), d.data).Err()
+}
+
+// reject drops a message like ack, pub/sub has no dead letter queue.
+func (b *pubsubBroker) reject(ctx context.Context, d *delivery) error {
+	return b.ack(ctx, d)
+}
+
+func (b *pubsubBroker) close() error {
+	return b.pubsub.Close()
+}
@@ -0,0 +1,130 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestPubSubBacklog(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "pubsub-backlog"
+	newWorker := func(channel string, opts ...Option) *Worker {
+		w := NewWorker(append([]Option{
+			WithAddr(endpoint),
+			WithChannel(channel),
+			WithDeliveryMode(PubSub),
+			WithPubSubBacklog(),
+		}, opts...)...)
+		t.Cleanup(func() { _ = w.Shutdown() })
+		return w
+	}
+
+	// messages published while no worker is subscribed to the channel, by
+	// a producer subscribed to another one
+	producer := newWorker("pubsub-producer")
+	for _, s := range []string{"a", "b"} {
+		m := job.NewMessage(mockMessage{Message: s})
+		_, err := producer.Publish(ctx, &m, channel)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, producer.Redis().ZCard(ctx, backlogKey(channel)).Val())
+
+	retried := false
+	w := newWorker(channel, WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+		if string(m.Payload()) == "c" && !retried {
+			retried = true
+			return Retry{After: 10 * time.Millisecond}
+		}
+		return nil
+	}))
+	var got []string
+	for range 2 {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		got = append(got, string(task.Payload()))
+		require.NoError(t, w.Run(ctx, task))
+	}
+	assert.Equal(t, []string{"a", "b"}, got)
+	assert.Zero(t, w.Redis().ZCard(ctx, backlogKey(channel)).Val())
+
+	// published messages are removed from the backlog once handled
+	m := job.NewMessage(mockMessage{Message: "c"})
+	_, err := producer.Publish(ctx, &m, channel)
+	require.NoError(t, err)
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(task.Payload()))
+	assert.EqualValues(t, 1, w.Redis().ZCard(ctx, backlogKey(channel)).Val())
+	require.NoError(t, w.Run(ctx, task))
+
+	// and a message retried later is back in the backlog once due
+	assert.Zero(t, w.Redis().ZCard(ctx, backlogKey(channel)).Val())
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, w.moveDelayed(ctx))
+	assert.EqualValues(t, 1, w.Redis().ZCard(ctx, backlogKey(channel)).Val())
+	task, err = w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(task.Payload()))
+	require.NoError(t, w.Run(ctx, task))
+	assert.Zero(t, w.Redis().ZCard(ctx, backlogKey(channel)).Val())
+}
+
+func TestPubSubBacklogReplay(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "pubsub-replay"
+	producer := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("pubsub-replay-producer"),
+		WithDeliveryMode(PubSub),
+		WithPubSubBacklog(),
+	)
+	defer producer.Shutdown()
+	publish := func(s string) {
+		m := job.NewMessage(mockMessage{Message: s})
+		_, err := producer.Publish(ctx, &m, channel)
+		require.NoError(t, err)
+	}
+	for _, s := range []string{"a", "b", "c"} {
+		publish(s)
+	}
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(PubSub),
+		WithPubSubBacklog(),
+	)
+	defer w.Shutdown()
+
+	// messages handled meanwhile by another worker are skipped, and the
+	// ones published since the worker started are only received once
+	backlog := w.Redis().ZRange(ctx, backlogKey(channel), 0, -1).Val()
+	require.Len(t, backlog, 3)
+	w.Redis().ZRem(ctx, backlogKey(channel), backlog[1])
+	publish("d")
+
+	var got []string
+	for range 3 {
+		task, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		got = append(got, string(task.Payload()))
+		require.NoError(t, w.Run(ctx, task))
+	}
+	assert.Equal(t, []string{"a", "c", "d"}, got)
+	_, err := w.Fetch(ctx, 100*time.Millisecond)
+	assert.Error(t, err)
+	assert.Zero(t, w.Redis().ZCard(ctx, backlogKey(channel)).Val())
+}
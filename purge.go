@@ -0,0 +1,77 @@
+package redisdb
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Purge deletes the messages waiting in the consumed channels and returns
+// how many were removed. Stream entries are trimmed so that consumer groups
+// survive; the messages of running jobs are removed as well and are not
+// dead-lettered if they fail. Pub/sub channels hold no messages.
+func (w *Worker) Purge(ctx context.Context) (int64, error) {
+	switch w.opts.mode {
+	case List:
+		return w.purgeKeys(ctx, func(pipe redis.Pipeliner, key string) *redis.IntCmd {
+			return pipe.LLen(ctx, key)
+		}, func(channel string) string { return channel })
+	case Stream:
+		var n int64
+		for _, channel := range w.opts.channels {
+			trimmed, err := w.rdb.XTrimMaxLen(ctx, channel, 0).Result()
+			if err != nil {
+				return n, err
+			}
+			n += trimmed
+		}
+		return n, nil
+	}
+	return 0, nil
+}
+
+// PurgeDead deletes the dead letter queues of the consumed channels and
+// returns how many messages were removed.
+func (w *Worker) PurgeDead(ctx context.Context) (int64, error) {
+	if w.opts.mode == PubSub {
+		return 0, nil
+	}
+	return w.purgeKeys(ctx, func(pipe redis.Pipeliner, key string) *redis.IntCmd {
+		return pipe.XLen(ctx, key)
+	}, deadKey)
+}
+
+// PurgeDelayed deletes the messages scheduled for later delivery on the
+// consumed channels and returns how many were removed.
+func (w *Worker) PurgeDelayed(ctx context.Context) (int64, error) {
+	return w.purgeKeys(ctx, func(pipe redis.Pipeliner, key string) *redis.IntCmd {
+		return pipe.ZCard(ctx, key)
+	}, delayedKey, delayedIDsKey)
+}
+
+// purgeKeys deletes the key of every consumed channel, along with its
+// others keys, counting its messages in the same transaction.
+func (w *Worker) purgeKeys(
+	ctx context.Context,
+	size func(redis.Pipeliner, string) *redis.IntCmd,
+	key func(string) string,
+	others ...func(string) string,
+) (int64, error) {
+	var n int64
+	for _, channel := range w.opts.channels {
+		var count *redis.IntCmd
+		_, err := w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			count = size(pipe, key(channel))
+			pipe.Del(ctx, key(channel))
+			for _, other := range others {
+				pipe.Del(ctx, other(channel))
+			}
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+		n += count.Val()
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			channel := "purge-" + mode.String()
+			w := NewWorker(
+				WithAddr(endpoint),
+				WithChannel(channel),
+				WithDeliveryMode(mode),
+			)
+
+			for _, v := range []string{"a", "b", "c"} {
+				m := job.NewMessage(mockMessage{Message: v})
+				require.NoError(t, w.Queue(&m))
+			}
+			m := job.NewMessage(mockMessage{Message: "later"})
+			require.NoError(t, w.schedule(ctx, channel, m.Bytes(), time.Now().Add(time.Hour)))
+			require.NoError(t, w.Redis().XAdd(ctx, &redis.XAddArgs{
+				Stream: deadKey(channel),
+				Values: map[string]any{streamPayloadField: "dead"},
+			}).Err())
+
+			n, err := w.Purge(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), n)
+			n, err = w.PurgeDead(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			n, err = w.PurgeDelayed(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+
+			_, err = w.Fetch(ctx, 100*time.Millisecond)
+			assert.Error(t, err)
+
+			// the channel is still usable
+			require.NoError(t, w.Queue(&m))
+			task, err := w.Fetch(ctx, time.Second)
+			require.NoError(t, err)
+			assert.NoError(t, w.Run(ctx, task))
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-queue/queue"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrQueueFull is returned when a job is queued onto a channel already
+// holding the maximum number of waiting messages, see WithMaxQueueLen.
+var ErrQueueFull = errors.New("redisdb: queue is full")
+
+// queueFullPollInterval is how often a producer blocked by a full channel
+// tries again, see WithBlockWhenFull.
+const queueFullPollInterval = 100 * time.Millisecond
+
+// queueFullError prefixes the error replied by boundedPushScript.
+const queueFullError = "QUEUEFULL"
+
+// boundedPushScript pushes the message ARGV[3] onto the list KEYS[1], or
+// adds it to the stream KEYS[1] in the field ARGV[4] when ARGV[2] names
+// the consumer group, unless ARGV[1] messages already wait in it. The
+// waiting entries of a stream are the lag of the group, which servers
+// before Redis 7 do not report: streams are then not bounded.
+var boundedPushScript = newQueueScript("bounded_push", `
+local max = tonumber(ARGV[1])
+if ARGV[2] == '' then
+  if redis.call('LLEN', KEYS[1]) >= max then
+    return redis.error_reply('QUEUEFULL queue is full')
+  end
+  return redis.call('LPUSH', KEYS[1], ARGV[3])
+end
+local waiting = 0
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  -- every entry waits until the group is created
+  waiting = redis.call('XLEN', KEYS[1])
+  for _, g in ipairs(redis.call('XINFO', 'GROUPS', KEYS[1])) do
+    local info = {}
+    for i = 1, #g, 2 do
+      info[g[i]] = g[i + 1]
+    end
+    if info['name'] == ARGV[2] then
+      waiting = info['lag'] or 0
+    end
+  end
+end
+if waiting >= max then
+  return redis.error_reply('QUEUEFULL queue is full')
+end
+return redis.call('XADD', KEYS[1], '*', ARGV[4], ARGV[3])
+`)
+
+// publish writes a new message of channel with rdb, checking the length of
+// the channel in the same step when it is bounded, see WithMaxQueueLen.
+func (w *Worker) publish(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error {
+	if w.opts.maxQueueLen <= 0 || (w.opts.mode != List && w.opts.mode != Stream) {
+		return w.broker.push(ctx, rdb, channel, data)
+	}
+
+	var group string
+	if w.opts.mode == Stream {
+		group = w.opts.consumerGroup
+	}
+	keys := []string{channel}
+	args := []interface{}{w.opts.maxQueueLen, group, data, streamPayloadField}
+	if _, ok := rdb.(redis.Pipeliner); ok {
+		// a missing script cannot be loaded again within a pipeline
+		return boundedPushScript.Eval(ctx, rdb, keys, args...).Err()
+	}
+	return boundedPushScript.run(ctx, rdb, w.functions, keys, args...).Err()
+}
+
+// queueFull maps the error of a bounded push to ErrQueueFull.
+func queueFull(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), queueFullError) {
+		return ErrQueueFull
+	}
+	return err
+}
+
+// waitNotFull pushes a message onto a full channel once messages were
+// taken from it, for up to the wait of WithBlockWhenFull. It returns
+// ErrQueueFull when the channel is still full by then, and the context
+// error when ctx is done first.
+func (w *Worker) waitNotFull(ctx context.Context, channel string, data []byte) error {
+	timer := time.NewTimer(w.opts.blockWhenFull)
+	defer timer.Stop()
+	ticker := time.NewTicker(queueFullPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.stop:
+			return queue.ErrQueueShutdown
+		case <-timer.C:
+			return ErrQueueFull
+		case <-ticker.C:
+		}
+		if err := queueFull(w.publish(ctx, w.rdb, channel, data)); !errors.Is(err, ErrQueueFull) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestMaxQueueLen(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "max-queue-len"
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+		WithMaxQueueLen(2),
+	)
+	defer w.Shutdown()
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	require.NoError(t, w.Queue(&m))
+	require.NoError(t, w.Queue(&m))
+	assert.ErrorIs(t, w.Queue(&m), ErrQueueFull)
+
+	n, err := w.Redis().LLen(ctx, channel).Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Run(ctx, task))
+	assert.NoError(t, w.Queue(&m))
+}
+
+func TestBlockWhenFull(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "block-when-full"
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+		WithMaxQueueLen(1),
+		WithBlockWhenFull(5*time.Second),
+	)
+	defer w.Shutdown()
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	require.NoError(t, w.Queue(&m))
+
+	tctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.QueueContext(tctx, &m), context.DeadlineExceeded)
+
+	queued := make(chan error, 1)
+	go func() {
+		queued <- w.Queue(&m)
+	}()
+	select {
+	case err := <-queued:
+		t.Fatalf("queued onto a full channel: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Run(ctx, task))
+	select {
+	case err := <-queued:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("producer still blocked")
+	}
+}
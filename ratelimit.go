@@ -0,0 +1,75 @@
+package redisdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitScript takes a token from a bucket holding up to ARGV[1] tokens
+// that refills completely every ARGV[2] milliseconds. It returns 0 when a
+// token was taken, otherwise the milliseconds until one is available.
+// The Redis clock is used so that all workers share the same time.
+//
+// Like the scripts of the package that are not queue operations, it is
+// run with Script.Run, which sends EVALSHA and falls back to EVAL when the
+// server answers NOSCRIPT, so scripts are reloaded after a restart or a
+// SCRIPT FLUSH.
+var rateLimitScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or capacity
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * capacity / interval)
+
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+else
+  wait = math.ceil((1 - tokens) * interval / capacity)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], interval * 2)
+return wait
+`)
+
+type rateLimit struct {
+	n   int
+	per time.Duration
+}
+
+func rateLimitKey(channel string) string {
+	return channel + ":ratelimit"
+}
+
+// waitRateLimit blocks until the shared rate limit of the channel allows
+// one more job to run.
+func (w *Worker) waitRateLimit(ctx context.Context, channel string) error {
+	for {
+		wait, err := rateLimitScript.Run(
+			ctx,
+			w.rdb,
+			[]string{rateLimitKey(channel)},
+			w.opts.rateLimit.n,
+			w.opts.rateLimit.per.Milliseconds(),
+		).Int64()
+		if err != nil {
+			return err
+		}
+		if wait == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(wait) * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package redisdb
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	// two workers share the same limit
+	var workers []*Worker
+	for i := 0; i < 2; i++ {
+		workers = append(workers, NewWorker(
+			WithAddr(endpoint),
+			WithChannel("ratelimit"),
+			WithDeliveryMode(List),
+			WithRateLimit(5, time.Second),
+		))
+	}
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		assert.NoError(t, workers[i%2].Run(ctx, &m))
+	}
+	// the first 5 jobs use the full bucket, the next 5 wait for refills
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.ErrorIs(t, workers[0].Run(timeoutCtx, &m), context.DeadlineExceeded)
+
+	for _, w := range workers {
+		assert.NoError(t, w.Shutdown())
+	}
+}
+
+func TestRateLimitSubMillisecond(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("ratelimit-fast"),
+		WithDeliveryMode(List),
+		WithRateLimit(1, time.Microsecond),
+	)
+	defer w.Shutdown()
+	assert.Equal(t, time.Millisecond, w.opts.rateLimit.per)
+	assert.Equal(t, 2*time.Millisecond, newOptions(WithRateLimit(1, 1500*time.Microsecond)).rateLimit.per)
+
+	// the tokens are refilled, not divided by a zero interval
+	for i := 0; i < 3; i++ {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		assert.NoError(t, w.Run(ctx, &m))
+	}
+	tokens, err := w.Redis().HGet(ctx, rateLimitKey("ratelimit-fast"), "tokens").Float64()
+	assert.NoError(t, err)
+	assert.False(t, math.IsNaN(tokens) || math.IsInf(tokens, 0))
+}
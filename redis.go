@@ -2,7 +2,10 @@ package redisdb
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -12,8 +15,10 @@ import (
 	"github.com/golang-queue/queue/core"
 	"github.com/golang-queue/queue/job"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/yassinebenaid/godump"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ core.Worker = (*Worker)(nil)
@@ -22,17 +27,52 @@ var _ core.Worker = (*Worker)(nil)
 type Worker struct {
 	// redis config
 	rdb      redis.Cmdable
-	pubsub   *redis.PubSub
-	channel  <-chan *redis.Message
+	broker   broker
 	stopFlag int32
 	stopOnce sync.Once
 	stop     chan struct{}
+	wg       sync.WaitGroup
 	opts     options
+	topology Topology
+	metrics  *instruments
+	prom     *promMetrics
+	tracer   trace.Tracer
+	log      *slog.Logger
+	// popFailures counts the consecutive failed reads from Redis.
+	popFailures int32
+	// deliveries maps the messages handed out by Request to their
+	// delivery so that Run can acknowledge them.
+	deliveries sync.Map
+	// depth caches the queue depth reported by Capacity and Usage.
+	depth depth
+	// id, startedAt and processed are reported in the worker registry.
+	id        string
+	startedAt time.Time
+	processed int64
+	// lastActive is when the consumer last fetched or finished a job, in
+	// nanoseconds, see Healthy.
+	lastActive int64
+	// throughput counts the queued and run jobs, see SuggestedWorkerCount.
+	throughput throughputs
+	// enqueuer batches the queued messages, see WithEnqueueFlushInterval.
+	enqueuer *enqueuer
+	// prefetcher reads messages ahead, see WithBufferSize.
+	prefetcher *prefetcher
+	// nextShard spreads the jobs over the shards, see WithShards.
+	nextShard uint32
+	// paused holds the paused channels, see Inspector.Pause.
+	paused pauseSet
+	// functions holds the queue scripts loaded as Redis Functions, nil
+	// when they run with EVALSHA.
+	functions *functionLibrary
+	// keyspace wakes the idle consumers, see WithKeyspaceNotifications.
+	keyspace *keyspaceWatcher
 }
 
 // NewWorker creates a new Worker instance with the provided options.
 // It initializes a Redis client based on the options and establishes a connection to the Redis server.
-// The Worker is responsible for subscribing to a Redis channel and receiving messages from it.
+// The Worker is responsible for sending messages to a Redis channel and receiving them from it
+// using the configured delivery mode.
 // It returns the created Worker instance.
 func NewWorker(opts ...Option) *Worker {
 	var err error
@@ -45,41 +85,22 @@ func NewWorker(opts ...Option) *Worker {
 		_ = godump.Dump(w.opts)
 	}
 
-	options := &redis.Options{
-		Addr:      w.opts.addr,
-		Username:  w.opts.username,
-		Password:  w.opts.password,
-		DB:        w.opts.db,
-		TLSConfig: w.opts.tls,
+	w.metrics, err = newInstruments(w.opts.meterProvider)
+	if err != nil {
+		w.opts.logger.Fatal(err)
 	}
-	w.rdb = redis.NewClient(options)
+	w.tracer = newTracer(w.opts.tracerProvider)
+	w.log = newLog(w.opts)
 
-	if w.opts.connectionString != "" {
-		options, err := redis.ParseURL(w.opts.connectionString)
-		if err != nil {
-			w.opts.logger.Fatal(err)
-		}
-		w.rdb = redis.NewClient(options)
+	if err := w.opts.checkManaged(); err != nil {
+		w.opts.logger.Fatal(err)
 	}
-
-	if w.opts.cluster {
-		w.rdb = redis.NewClusterClient(&redis.ClusterOptions{
-			Addrs:     strings.Split(w.opts.addr, ","),
-			Username:  w.opts.username,
-			Password:  w.opts.password,
-			TLSConfig: w.opts.tls,
-		})
+	if err := w.opts.checkBroadcast(); err != nil {
+		w.opts.logger.Fatal(err)
 	}
-
-	if w.opts.sentinel {
-		w.rdb = redis.NewFailoverClient(&redis.FailoverOptions{
-			MasterName:    w.opts.masterName,
-			SentinelAddrs: strings.Split(w.opts.addr, ","),
-			Username:      w.opts.username,
-			Password:      w.opts.password,
-			DB:            w.opts.db,
-			TLSConfig:     w.opts.tls,
-		})
+	w.rdb, err = newClient(w.opts)
+	if err != nil {
+		w.opts.logger.Fatal(err)
 	}
 
 	_, err = w.rdb.Ping(context.Background()).Result()
@@ -88,34 +109,338 @@ func NewWorker(opts ...Option) *Worker {
 	}
 
 	ctx := context.Background()
+	w.functions = w.loadFunctions(ctx)
+
+	if err := w.claimChannels(ctx); err != nil {
+		w.opts.logger.Fatal(err)
+	}
+	if err := w.loadPaused(ctx); err != nil {
+		w.opts.logger.Fatal(err)
+	}
+	if w.keyspace, err = w.watchKeyspace(ctx); err != nil {
+		w.opts.logger.Fatal(err)
+	}
+
+	switch w.opts.mode {
+	case List:
+		w.broker, err = newListBroker(ctx, w, w.opts.channels)
+	case Stream:
+		w.broker, err = newStreamBroker(ctx, w)
+	case Asynq:
+		w.broker, err = newAsynqBroker(ctx, w)
+	case Sidekiq:
+		w.broker, err = newSidekiqBroker(ctx, w)
+	case Celery:
+		w.broker = newCeleryBroker(w)
+	default:
+		w.broker, err = newPubSubBroker(ctx, w)
+	}
+	if err != nil {
+		w.opts.logger.Fatal(err)
+	}
+	if w.opts.enqueueFlushInterval > 0 || w.opts.enqueueBatchSize > 1 {
+		w.enqueuer = newEnqueuer(w)
+	}
+	if w.opts.bufferSize > 0 && !w.opts.strictOrder {
+		w.prefetcher = newPrefetcher(w)
+	}
 
-	switch v := w.rdb.(type) {
-	case *redis.Client:
-		w.pubsub = v.Subscribe(ctx, w.opts.channelName)
-	case *redis.ClusterClient:
-		w.pubsub = v.Subscribe(ctx, w.opts.channelName)
+	if w.opts.mode != PubSub {
+		err = w.metrics.observeDeadLetters(w.DeadLetterStats)
+		if err != nil {
+			w.opts.logger.Fatal(err)
+		}
+		if w.opts.deadLetterAlert.fn != nil {
+			w.wg.Add(1)
+			go w.watchDeadLetters()
+		}
 	}
 
-	var ropts []redis.ChannelOption
+	if w.opts.metricsRegistry != nil {
+		w.prom, err = newPromMetrics(w, w.opts.metricsRegistry)
+		if err != nil {
+			w.opts.logger.Fatal(err)
+		}
+	}
 
-	if w.opts.channelSize > 1 {
-		ropts = append(ropts, redis.WithChannelSize(w.opts.channelSize))
+	w.wg.Add(1)
+	go w.watchDelayed()
+	if w.opts.mode == List || w.opts.mode == Stream {
+		w.wg.Add(1)
+		go w.watchPaused()
 	}
 
-	w.channel = w.pubsub.Channel(ropts...)
-	// make sure the connection is successful
-	if err := w.pubsub.Ping(ctx); err != nil {
+	w.id = ulid.Make().String()
+	w.startedAt = time.Now()
+	if err := w.heartbeat(ctx); err != nil {
 		w.opts.logger.Fatal(err)
 	}
+	w.wg.Add(1)
+	go w.watchHeartbeat()
+
+	if len(w.lockKeys()) > 0 {
+		w.wg.Add(1)
+		go w.watchLocks()
+	}
+
+	w.topology = newTopology(w.opts)
+	w.log.Info("worker started", "topology", w.topology)
+	w.notify(StateReady)
 
 	return w
 }
 
+// newClient returns the Redis client described by the options.
+func newClient(opts options) (redis.Cmdable, error) {
+	switch {
+	case opts.sentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    opts.masterName,
+			SentinelAddrs: strings.Split(opts.addr, ","),
+			Username:      opts.username,
+			Password:      opts.password,
+			DB:            opts.db,
+			TLSConfig:     opts.tls,
+		}), nil
+	case opts.cluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     strings.Split(opts.addr, ","),
+			Username:  opts.username,
+			Password:  opts.password,
+			TLSConfig: opts.tls,
+			// managed services may refuse CLIENT SETINFO
+			DisableIndentity: opts.managed,
+		}), nil
+	case opts.connectionString != "":
+		options, err := redis.ParseURL(opts.connectionString)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewClient(options), nil
+	}
+	return redis.NewClient(&redis.Options{
+		Addr:      opts.addr,
+		Username:  opts.username,
+		Password:  opts.password,
+		DB:        opts.db,
+		TLSConfig: opts.tls,
+	}), nil
+}
+
+// closeClient closes the client created by newClient.
+func closeClient(rdb redis.Cmdable) error {
+	if c, ok := rdb.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Topology returns the effective topology the worker was started with.
+func (w *Worker) Topology() Topology {
+	return w.topology
+}
+
+// Redis returns the client used by the worker. The worker owns the
+// client: do not close it, use Shutdown instead.
+func (w *Worker) Redis() redis.Cmdable {
+	return w.rdb
+}
+
 // Run to execute new task
-func (w *Worker) Run(ctx context.Context, task core.TaskMessage) error {
+func (w *Worker) Run(ctx context.Context, task core.TaskMessage) (err error) {
+	channel := w.opts.channels[0]
+	var d *delivery
+	if v, ok := w.deliveries.Load(task); ok {
+		d = v.(*delivery)
+		channel = d.channel
+		ctx = context.WithValue(ctx, jobIDKey{}, d.env.ID)
+		ctx = w.contextWithHeaders(ctx, d.env.Headers)
+	}
+
+	m, _ := task.(*job.Message)
+	ack := &Acknowledger{w: w, m: m}
+
+	if w.opts.idempotency.enabled {
+		key, ok, claimErr := w.claimJob(ctx, channel, task)
+		if claimErr != nil {
+			return claimErr
+		}
+		if !ok {
+			if m != nil {
+				w.settle(m, nil)
+			}
+			if d != nil && d.env.Group != "" && w.jobCancelled(ctx, channel, task) {
+				w.finishGroup(context.WithoutCancel(ctx), d.env.Group, 1, 1)
+			}
+			return nil
+		}
+		defer func() {
+			// a rejected job runs again when it is redelivered
+			if ack.nacked.Load() {
+				w.finishJob(key, errNacked)
+				return
+			}
+			w.finishJob(key, err)
+		}()
+	}
+
+	if limit := w.concurrencyLimit(channel); limit > 0 {
+		release, err := w.acquireSlot(ctx, ack, concurrencyKey(channel), limit)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if jobType, limit := w.typeConcurrencyLimit(task); limit > 0 {
+		release, err := w.acquireSlot(ctx, ack, w.opts.key(typeConcurrencyKey(jobType)), limit)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if w.opts.rateLimit.n > 0 {
+		if err := w.waitRateLimit(ctx, channel); err != nil {
+			return err
+		}
+	}
+
+	if m != nil && w.opts.ackPolicy == AckBeforeRun {
+		w.settle(m, nil)
+	}
+
+	info := JobInfo{Channel: w.opts.channelName(channel), Attempt: 1, Payload: task.Payload()}
+	if d != nil {
+		info.ID = d.env.ID
+		info.Attempt = d.env.Attempts + int(atomic.AddInt32(&d.attempts, 1))
+		ctx = context.WithValue(ctx, metadataKey{}, d.env.metadata(info.Attempt))
+	}
+
+	start := time.Now()
+	ctx = context.WithValue(ctx, clientKey{}, w.rdb)
+	ctx = context.WithValue(ctx, channelKey{}, info.Channel)
+	ctx = context.WithValue(ctx, ackKey{}, ack)
+	if d != nil {
+		w.metrics.recordLatency(ctx, channel, d.env.EnqueuedAt)
+	}
+	ctx, span := w.startProcessSpan(ctx, channel, d)
+	w.opts.hooks.start(ctx, info)
+	err = w.runFunc(ctx, task)
+	info.Duration = time.Since(start)
+	w.throughput.recordDone(channel, info.Duration)
+	var panicErr *PanicError
+	if m != nil && w.opts.panicDeadLetter && errors.As(err, &panicErr) {
+		// no more attempts, the message goes to the dead letter queue
+		m.RetryCount = 0
+	}
+	atomic.AddInt64(&w.processed, 1)
+	w.markActive()
+	endSpan(span, err)
+	w.metrics.recordProcessed(ctx, channel, start, err)
+	w.prom.recordProcessed(channel, start, err,
+		err != nil && m != nil && m.RetryCount > 0 && ctx.Err() == nil)
+	w.opts.hooks.finish(ctx, info, err)
+	if err != nil && d != nil {
+		w.recordFailure(context.WithoutCancel(ctx), info, err)
+	}
+
+	attrs := []any{"channel", channel, "duration", info.Duration, "job_id", info.ID, "attempt", info.Attempt}
+	w.log.Debug("job finished", append(attrs, "error", err)...)
+
+	var retry Retry
+	if errors.As(err, &retry) {
+		if err := ack.Nack(retry.After); err != nil && !errors.Is(err, ErrNoDelivery) {
+			w.log.Error("failed to reschedule message", append(attrs, "error", err)...)
+		}
+	} else if err != nil && m != nil && m.RetryCount > 0 && ctx.Err() == nil && w.opts.retryDelay != nil {
+		if err := w.retryLater(ack, m, info.Attempt, err); err != nil && !errors.Is(err, ErrNoDelivery) {
+			w.log.Error("failed to reschedule message", append(attrs, "error", err)...)
+		}
+	}
+	if err != nil && ack.nacked.Load() {
+		w.log.Warn("job rejected for redelivery", append(attrs, "error", err)...)
+		w.opts.hooks.retry(ctx, info, err)
+		return nil
+	}
+
+	// the queue retries failed jobs by calling Run again with the same
+	// message, so only settle the delivery once no attempts are left or
+	// the job timed out
+	if m != nil && (err == nil || m.RetryCount == 0 || ctx.Err() != nil) {
+		// with AckManual, the next job is queued by Ack
+		if err == nil && d != nil && len(d.env.Next) > 0 && w.opts.ackPolicy != AckManual {
+			w.queueNext(m, d)
+		}
+		if err == nil && w.opts.ackPolicy == AckManual {
+			w.forget(m)
+		} else {
+			_, tracked := w.deliveries.Load(m)
+			w.settle(m, err)
+			if err != nil && tracked && w.opts.mode != PubSub {
+				w.recordDeadLettered(context.WithoutCancel(ctx), info.ID)
+				w.opts.hooks.deadLetter(ctx, info, err)
+			}
+		}
+		if d != nil && d.env.Group != "" {
+			var failed int64
+			if err != nil {
+				failed = 1
+			}
+			w.finishGroup(context.WithoutCancel(ctx), d.env.Group, 1, failed)
+		}
+	} else if m != nil && err != nil {
+		w.opts.hooks.retry(ctx, info, err)
+	}
+
+	return err
+}
+
+// runFunc calls the run func of the worker and passes its errors and
+// panics to the error handler. Panics are raised again for the queue,
+// unless they are handled by the panic handler and turned into a
+// PanicError.
+func (w *Worker) runFunc(ctx context.Context, task core.TaskMessage) (err error) {
+	recoverPanics := w.opts.panicHandler != nil || w.opts.panicDeadLetter
+	if w.opts.errorHandler == nil && !recoverPanics {
+		return w.opts.runFunc(ctx, task)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if !recoverPanics {
+				w.opts.errorHandler(ctx, task, fmt.Errorf("panic: %v", r))
+				panic(r)
+			}
+			stack := debug.Stack()
+			if w.opts.panicHandler != nil {
+				w.opts.panicHandler(ctx, task, r, stack)
+			}
+			err = &PanicError{Value: r, Stack: stack}
+		}
+		if err != nil && w.opts.errorHandler != nil {
+			w.opts.errorHandler(ctx, task, err)
+		}
+	}()
 	return w.opts.runFunc(ctx, task)
 }
 
+// settle acknowledges the delivery of a finished job, or rejects it when
+// the job failed, and logs the errors.
+func (w *Worker) settle(m *job.Message, runErr error) {
+	if err := w.finish(m, runErr); err != nil {
+		w.log.Error("failed to settle message", "error", err)
+	}
+}
+
+func (w *Worker) unlock(l *orderLock) {
+	if l == nil {
+		return
+	}
+	if err := l.release(context.Background()); err != nil {
+		w.log.Error("failed to release ordering lock", "error", err)
+	}
+}
+
 // Shutdown worker
 func (w *Worker) Shutdown() error {
 	if !atomic.CompareAndSwapInt32(&w.stopFlag, 0, 1) {
@@ -123,58 +448,228 @@ func (w *Worker) Shutdown() error {
 	}
 
 	w.stopOnce.Do(func() {
-		w.pubsub.Close()
-		switch v := w.rdb.(type) {
-		case *redis.Client:
-			v.Close()
-		case *redis.ClusterClient:
-			v.Close()
-		}
+		w.notify(StateDraining)
 		close(w.stop)
+		if w.opts.drain || w.opts.shutdownTimeout > 0 {
+			if n := w.waitInflight(w.opts.shutdownTimeout); n > 0 {
+				w.log.Warn("shutdown timed out with unsettled messages", "count", n)
+			}
+		}
+		w.wg.Wait()
+		if w.enqueuer != nil {
+			w.enqueuer.close()
+		}
+		if w.prefetcher != nil {
+			w.prefetcher.close()
+		}
+		w.broker.close()
+		if err := w.flushThroughput(context.Background()); err != nil {
+			w.log.Error("failed to write throughput", "error", err)
+		}
+		if err := w.unregister(context.Background()); err != nil {
+			w.log.Error("failed to unregister worker", "error", err)
+		}
+		_ = closeClient(w.rdb)
+		w.notify(StateStopped)
 	})
 	return nil
 }
 
 // Queue send notification to queue
 func (w *Worker) Queue(job core.TaskMessage) error {
+	return w.QueueContext(context.Background(), job)
+}
+
+// QueueContext sends a message like Queue, and propagates the values of ctx
+// registered with WithContextKey to the context of its handler.
+func (w *Worker) QueueContext(ctx context.Context, job core.TaskMessage) error {
+	_, err := w.QueueWithID(ctx, job)
+	return err
+}
+
+// QueueWithID sends a message like QueueContext and returns the unique ID
+// assigned to its job, a ULID. Handlers read it with JobIDFromContext.
+func (w *Worker) QueueWithID(ctx context.Context, job core.TaskMessage) (string, error) {
 	if atomic.LoadInt32(&w.stopFlag) == 1 {
-		return queue.ErrQueueShutdown
+		return "", queue.ErrQueueShutdown
 	}
 
-	ctx := context.Background()
+	env := w.newEnvelope(ctx, ulid.Make().String())
+	if err := w.send(ctx, job, env); err != nil {
+		return "", err
+	}
+	return env.ID, nil
+}
 
-	// Publish a message.
-	err := w.rdb.Publish(ctx, w.opts.channelName, job.Bytes()).Err()
+// send queues a job with the given envelope.
+func (w *Worker) send(ctx context.Context, job core.TaskMessage, env envelope) error {
+	channel, m, err := w.route(job, env)
 	if err != nil {
 		return err
 	}
-
+	ctx, span, carrier := w.startPublishSpan(ctx, channel, env.ID)
+	env.Trace = carrier
+	var data []byte
+	m, err = w.storeJSONPayload(ctx, channel, m, &env)
+	if err == nil {
+		data, err = w.encode(m, env)
+	}
+	if err == nil {
+		err = w.push(ctx, channel, data)
+	}
+	endSpan(span, err)
+	if err != nil {
+		return err
+	}
+	w.metrics.recordPublished(ctx, channel)
+	w.throughput.recordQueued(channel)
 	return nil
 }
 
-// Request a new task
+// Request a new task. It blocks for up to the configured block time
+// and returns early when the worker is shut down.
 func (w *Worker) Request() (core.TaskMessage, error) {
-	clock := 0
-loop:
+	return w.Fetch(context.Background(), w.opts.blockTime)
+}
+
+// Fetch waits up to wait for the next message, for integrations that pull
+// jobs on demand instead of running a queue. Pass the returned task to Run
+// to process and acknowledge it. Fetch returns queue.ErrNoTaskInQueue when
+// no message arrives in time, and the context error when ctx is done first.
+func (w *Worker) Fetch(ctx context.Context, wait time.Duration) (core.TaskMessage, error) {
+	if atomic.LoadInt32(&w.stopFlag) == 1 {
+		return nil, queue.ErrQueueHasBeenClosed
+	}
+	w.markActive()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// blocking Redis commands are not interrupted by the context,
+	// so never block past its deadline
+	deadline, capped := ctx.Deadline()
+	if capped && time.Until(deadline) < wait {
+		wait = time.Until(deadline)
+	} else {
+		capped = false
+	}
+
+	var lock *orderLock
+	if w.opts.strictOrder && w.opts.mode != PubSub {
+		start := time.Now()
+		l, err := w.lockChannels(ctx, wait)
+		if err != nil {
+			return nil, w.fetchError(ctx, err, capped)
+		}
+		lock = l
+		// a zero block time waits forever, always leave the pop some time
+		wait = max(wait-time.Since(start), time.Millisecond)
+	}
+
+	var (
+		d    *delivery
+		data *job.Message
+		env  envelope
+	)
 	for {
-		select {
-		case task, ok := <-w.channel:
-			if !ok {
-				return nil, queue.ErrQueueHasBeenClosed
-			}
-			var data job.Message
-			err := json.Unmarshal([]byte(task.Payload), &data)
-			if err != nil {
-				return nil, err
+		start := time.Now()
+		var err error
+		d, err = w.pop(ctx, wait)
+		if err != nil {
+			w.unlock(lock)
+			err = w.fetchError(ctx, err, capped)
+			if w.isRedisError(ctx, err) {
+				w.waitReconnect(ctx, wait)
 			}
-			return &data, nil
-		case <-time.After(1 * time.Second):
-			if clock == 5 {
-				break loop
+			return nil, err
+		}
+		atomic.StoreInt32(&w.popFailures, 0)
+		w.metrics.recordReceived(ctx, d.channel)
+
+		data, env, err = w.decode(d.data)
+		if err != nil {
+			// nothing can process a malformed message
+			_ = w.broker.reject(context.Background(), d)
+			w.unlock(lock)
+			return nil, err
+		}
+		if !env.expired(time.Now()) {
+			break
+		}
+		// skip the expired job and wait for the next one
+		w.expire(d, env)
+		wait = max(wait-time.Since(start), time.Millisecond)
+	}
+	d.env = env
+	if err := w.loadJSONPayload(ctx, data, env); err != nil {
+		if errors.Is(err, ErrPayloadNotFound) {
+			if w.broker.reject(context.Background(), d) == nil {
+				w.finishGroup(context.Background(), env.Group, 1, 1)
 			}
-			clock += 1
+		} else if rerr := w.broker.requeue(context.Background(), d, d.data); rerr != nil {
+			w.log.Error("failed to requeue message", "channel", d.channel, "job_id", env.ID, "error", rerr)
+		}
+		w.unlock(lock)
+		return nil, err
+	}
+	if lock != nil {
+		if err := lock.extend(ctx, data); err != nil {
+			w.log.Error("failed to extend ordering lock", "channel", d.channel, "error", err)
+		}
+		d.lock = lock
+	}
+	w.deliveries.Store(data, d)
+
+	// the worker was shut down while the message was read, nothing
+	// will run it
+	if atomic.LoadInt32(&w.stopFlag) == 1 {
+		w.deliveries.Delete(data)
+		if err := w.broker.requeue(context.Background(), d, d.data); err != nil {
+			w.log.Error("failed to requeue message", "channel", d.channel, "job_id", d.env.ID, "error", err)
+		} else {
+			w.metrics.recordRedelivered(ctx, d.channel)
 		}
+		w.unlock(lock)
+		return nil, queue.ErrQueueHasBeenClosed
+	}
+
+	return data, nil
+}
+
+// isRedisError reports whether a Fetch error comes from Redis rather than
+// from an empty queue, the context or the shutdown.
+func (w *Worker) isRedisError(ctx context.Context, err error) bool {
+	return ctx.Err() == nil &&
+		!errors.Is(err, queue.ErrNoTaskInQueue) &&
+		!errors.Is(err, queue.ErrQueueHasBeenClosed) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// waitReconnect backs off after a failed read, for up to wait, so that the
+// callers of Fetch do not hammer an unreachable server.
+func (w *Worker) waitReconnect(ctx context.Context, wait time.Duration) {
+	n := atomic.AddInt32(&w.popFailures, 1)
+	delay := min(w.reconnectBackoff().Delay(int(n)), wait)
+	select {
+	case <-w.stop:
+	case <-ctx.Done():
+	case <-time.After(delay):
 	}
+}
 
-	return nil, queue.ErrNoTaskInQueue
+// fetchError maps the error of a failed pop to the error returned by
+// Fetch. capped is set when the pop waited until the context deadline.
+func (w *Worker) fetchError(ctx context.Context, err error, capped bool) error {
+	// blocking reads fail with a closed connection during shutdown
+	if atomic.LoadInt32(&w.stopFlag) == 1 {
+		return queue.ErrQueueHasBeenClosed
+	}
+	if errors.Is(err, queue.ErrNoTaskInQueue) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if capped {
+			return context.DeadlineExceeded
+		}
+	}
+	return err
 }
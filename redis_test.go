@@ -14,6 +14,7 @@ import (
 	"github.com/golang-queue/queue/core"
 	"github.com/golang-queue/queue/job"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -98,8 +99,12 @@ func setupRedisCluserContainer(ctx context.Context, t *testing.T) (testcontainer
 }
 
 func setupRedisContainer(ctx context.Context, t *testing.T) (testcontainers.Container, string) {
+	return setupRedisImage(ctx, t, "redis:6")
+}
+
+func setupRedisImage(ctx context.Context, t *testing.T, image string) (testcontainers.Container, string) {
 	req := testcontainers.ContainerRequest{
-		Image:        "redis:6",
+		Image:        image,
 		ExposedPorts: []string{"6379/tcp"},
 		WaitingFor: wait.NewExecStrategy(
 			[]string{"redis-cli", "-h", "localhost", "-p", "6379", "ping"},
@@ -490,3 +495,412 @@ func TestGoroutinePanic(t *testing.T) {
 	assert.Error(t, q.Queue(m))
 	q.Wait()
 }
+
+func TestTopology(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("topology"),
+		WithDB(2),
+	)
+
+	topology := w.Topology()
+	assert.Equal(t, "pubsub", topology.Mode)
+	assert.Equal(t, []string{"topology"}, topology.Channels)
+	assert.Equal(t, "standalone", topology.Server)
+	assert.Equal(t, []string{endpoint}, topology.Addrs)
+	assert.Equal(t, 2, topology.DB)
+	assert.False(t, topology.TLS)
+	assert.Equal(t, RetryTopology{Jitter: "none"}, topology.Retry)
+	assert.Contains(t, topology.String(), `"channels":["topology"]`)
+	assert.NoError(t, w.Shutdown())
+
+	retry := newTopology(newOptions(
+		WithBackoff(ConstantBackoff(time.Second)),
+		WithRetryJitter(FullJitter),
+		WithRetryDelayFunc(func(int, error, core.QueuedMessage) time.Duration { return -1 }),
+	)).Retry
+	assert.Equal(t, RetryTopology{Backoff: true, Jitter: "full", DelayFunc: true}, retry)
+}
+
+func TestRequestBlockTime(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("blockTime"),
+		WithBlockTime(200*time.Millisecond),
+	)
+
+	start := time.Now()
+	task, err := w.Request()
+	assert.Nil(t, task)
+	assert.Equal(t, queue.ErrNoTaskInQueue, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		assert.NoError(t, w.Shutdown())
+	}()
+	_, err = w.Request()
+	assert.Equal(t, queue.ErrQueueHasBeenClosed, err)
+}
+
+func TestDeliveryModes(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{PubSub, List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			rets := make(chan string, 2)
+			w := NewWorker(
+				WithAddr(endpoint),
+				WithChannel("mode-"+mode.String()),
+				WithDeliveryMode(mode),
+				WithBlockTime(100*time.Millisecond),
+				WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+					rets <- string(m.Payload())
+					return nil
+				}),
+			)
+			assert.Equal(t, mode.String(), w.Topology().Mode)
+			q, err := queue.NewQueue(
+				queue.WithWorker(w),
+				queue.WithWorkerCount(2),
+			)
+			assert.NoError(t, err)
+			q.Start()
+			time.Sleep(100 * time.Millisecond)
+			assert.NoError(t, q.Queue(mockMessage{Message: "foo"}))
+			assert.NoError(t, q.Queue(mockMessage{Message: "bar"}))
+			assert.ElementsMatch(t, []string{"foo", "bar"}, []string{<-rets, <-rets})
+			q.Release()
+		})
+	}
+}
+
+func TestListKeepsMessagesWithoutWorker(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	// producer only: nothing consumes while messages are queued
+	producer := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("list-offline"),
+		WithDeliveryMode(List),
+	)
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, producer.Queue(&m))
+	assert.NoError(t, producer.Shutdown())
+
+	consumer := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("list-offline"),
+		WithDeliveryMode(List),
+		WithBlockTime(100*time.Millisecond),
+	)
+	task, err := consumer.Request()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(task.Payload()))
+	assert.NoError(t, consumer.Shutdown())
+}
+
+func TestHandlerRedisClient(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("client"),
+		WithDeliveryMode(List),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			return Client(ctx).Incr(ctx, "client:counter").Err()
+		}),
+	)
+	assert.Nil(t, Client(ctx))
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.Run(ctx, &m))
+	assert.NoError(t, w.Run(ctx, &m))
+	assert.Equal(t, "2", w.Redis().Get(ctx, "client:counter").Val())
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestListRecoverProcessing(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w1 := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("reliable"),
+		WithDeliveryMode(List),
+		WithBlockTime(100*time.Millisecond),
+	)
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w1.Queue(&m))
+	// take the message without running it
+	_, err := w1.Request()
+	assert.NoError(t, err)
+	processing := processingKey("reliable", w1.broker.(*listBroker).consumer)
+	assert.Equal(t, int64(1), w1.Redis().LLen(ctx, processing).Val())
+	assert.NoError(t, w1.Shutdown())
+
+	// pretend the first worker stopped sending heartbeats long ago
+	rdb := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer rdb.Close()
+	assert.NoError(t, rdb.ZAdd(ctx, "reliable:consumers", redis.Z{
+		Score:  1,
+		Member: w1.broker.(*listBroker).consumer,
+	}).Err())
+
+	w2 := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("reliable"),
+		WithDeliveryMode(List),
+		WithBlockTime(100*time.Millisecond),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			return errors.New("failed")
+		}),
+	)
+	task, err := w2.Request()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(task.Payload()))
+	assert.Error(t, w2.Run(ctx, task))
+	// failed jobs are dead-lettered
+	assert.Equal(t, int64(0), rdb.LLen(ctx, processing).Val())
+	assert.Equal(t, int64(1), rdb.XLen(ctx, "reliable:dead").Val())
+	assert.NoError(t, w2.Shutdown())
+}
+
+func TestListRecoverOwnProcessing(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	newWorker := func() *Worker {
+		return NewWorker(
+			WithAddr(endpoint),
+			WithChannel("restarted"),
+			WithDeliveryMode(List),
+			WithConsumerName("worker-1"),
+			WithBlockTime(100*time.Millisecond),
+		)
+	}
+	w1 := newWorker()
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w1.Queue(&m))
+	// take the message without running it
+	_, err := w1.Request()
+	assert.NoError(t, err)
+	assert.NoError(t, w1.Shutdown())
+
+	// restarted right away, the consumer still looks alive but gets its
+	// message back
+	w2 := newWorker()
+	defer w2.Shutdown()
+	assert.Zero(t, w2.Redis().LLen(ctx, processingKey("restarted", "worker-1")).Val())
+	task, err := w2.Request()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(task.Payload()))
+	assert.NoError(t, w2.Run(ctx, task))
+}
+
+func TestListClaim(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("claimed"),
+		WithDeliveryMode(List),
+		WithBlockTime(100*time.Millisecond),
+	)
+	b := w.broker.(*listBroker)
+	rdb := w.Redis()
+
+	// a consumer whose heartbeat is stale claims a message
+	assert.NoError(t, rdb.ZAdd(ctx, consumersKey("claimed"), redis.Z{Score: 1, Member: b.consumer}).Err())
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.Queue(&m))
+	_, err := w.Request()
+	assert.NoError(t, err)
+	// the claim is recorded with the pop, so the message is not recovered
+	assert.Greater(t, rdb.ZScore(ctx, consumersKey("claimed"), b.consumer).Val(), float64(1))
+	assert.NoError(t, b.requeueExpired(ctx, "claimed"))
+	assert.Equal(t, int64(1), rdb.LLen(ctx, processingKey("claimed", b.consumer)).Val())
+	assert.Equal(t, int64(0), rdb.LLen(ctx, "claimed").Val())
+
+	// and an empty queue waits for the block time, not a whole second
+	start := time.Now()
+	_, err = w.Request()
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestMultipleChannels(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	for _, mode := range []DeliveryMode{List, Stream} {
+		t.Run(mode.String(), func(t *testing.T) {
+			high := "high-" + mode.String()
+			low := "low-" + mode.String()
+			producer := NewWorker(
+				WithAddr(endpoint),
+				WithChannel(low),
+				WithDeliveryMode(mode),
+			)
+			w := NewWorker(
+				WithAddr(endpoint),
+				WithChannel(high, low),
+				WithDeliveryMode(mode),
+				WithBlockTime(200*time.Millisecond),
+			)
+
+			m := job.NewMessage(mockMessage{Message: "low"})
+			assert.NoError(t, producer.Queue(&m))
+			m = job.NewMessage(mockMessage{Message: "high"})
+			assert.NoError(t, w.Queue(&m))
+
+			// the first channel has priority
+			for _, want := range []string{"high", "low"} {
+				task, err := w.Request()
+				assert.NoError(t, err)
+				assert.Equal(t, want, string(task.Payload()))
+				assert.NoError(t, w.Run(ctx, task))
+			}
+			_, err := w.Request()
+			assert.Equal(t, queue.ErrNoTaskInQueue, err)
+
+			assert.NoError(t, producer.Shutdown())
+			assert.NoError(t, w.Shutdown())
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("fetch"),
+		WithDeliveryMode(Stream),
+	)
+
+	_, err := w.Fetch(ctx, 100*time.Millisecond)
+	assert.Equal(t, queue.ErrNoTaskInQueue, err)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = w.Fetch(cancelCtx, time.Second)
+	assert.Equal(t, context.Canceled, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = w.Fetch(timeoutCtx, 10*time.Second)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, w.Queue(&m))
+	task, err := w.Fetch(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(task.Payload()))
+	assert.NoError(t, w.Run(ctx, task))
+
+	pending, err := w.Redis().XPending(ctx, "fetch", defaultConsumerGroup).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), pending.Count)
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestChannelPattern(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	rets := make(chan string, 2)
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannelPattern("events.*"),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			rets <- ChannelFromContext(ctx) + ":" + string(m.Payload())
+			return nil
+		}),
+	)
+	q, err := queue.NewQueue(
+		queue.WithWorker(w),
+		queue.WithWorkerCount(1),
+	)
+	assert.NoError(t, err)
+	q.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	for _, channel := range []string{"events.created", "events.deleted"} {
+		producer := NewWorker(
+			WithAddr(endpoint),
+			WithChannel(channel),
+		)
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		assert.NoError(t, producer.Queue(&m))
+		assert.NoError(t, producer.Shutdown())
+	}
+
+	assert.ElementsMatch(t, []string{
+		"events.created:foo",
+		"events.deleted:foo",
+	}, []string{<-rets, <-rets})
+	q.Release()
+}
+
+func TestConsumerGroups(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	// every group receives all the messages of the stream
+	var workers []*Worker
+	for _, group := range []string{"billing", "audit"} {
+		w := NewWorker(
+			WithAddr(endpoint),
+			WithChannel("groups"),
+			WithDeliveryMode(Stream),
+			WithConsumerGroup(group),
+			WithConsumerName(group+"-1"),
+		)
+		assert.Equal(t, group, w.Topology().Group)
+		assert.Equal(t, group+"-1", w.Topology().Consumer)
+		workers = append(workers, w)
+	}
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	assert.NoError(t, workers[0].Queue(&m))
+
+	for _, w := range workers {
+		n, err := w.DrainOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+
+	groups, err := workers[0].Redis().XInfoGroups(ctx, "groups").Result()
+	assert.NoError(t, err)
+	assert.Len(t, groups, 2)
+	for _, w := range workers {
+		assert.NoError(t, w.Shutdown())
+	}
+}
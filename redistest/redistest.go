@@ -0,0 +1,58 @@
+// Package redistest starts disposable Redis servers for the integration
+// tests of code using redisdb workers. It runs Redis in Docker with
+// testcontainers, see the memory package for tests without Docker.
+package redistest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-queue/redisdb"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Image is the Redis image started by Start.
+var Image = "redis:6"
+
+// Start starts a Redis container, removed at the end of the test, and
+// returns its endpoint.
+func Start(tb testing.TB) string {
+	tb.Helper()
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        Image,
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor: wait.NewExecStrategy(
+			[]string{"redis-cli", "-h", "localhost", "-p", "6379", "ping"},
+		),
+	}
+	redisC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	testcontainers.CleanupContainer(tb, redisC)
+	if err != nil {
+		tb.Fatalf("redistest: start redis: %v", err)
+	}
+
+	endpoint, err := redisC.Endpoint(ctx, "")
+	if err != nil {
+		tb.Fatalf("redistest: redis endpoint: %v", err)
+	}
+	return endpoint
+}
+
+// Setup starts a Redis container and returns a worker connected to it
+// with opts, shut down at the end of the test, along with the endpoint of
+// the container.
+func Setup(tb testing.TB, opts ...redisdb.Option) (*redisdb.Worker, string) {
+	tb.Helper()
+	endpoint := Start(tb)
+	w := redisdb.NewWorker(append([]redisdb.Option{redisdb.WithAddr(endpoint)}, opts...)...)
+	tb.Cleanup(func() {
+		_ = w.Shutdown()
+	})
+	return w, endpoint
+}
@@ -0,0 +1,32 @@
+package redistest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/golang-queue/redisdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type message string
+
+func (m message) Bytes() []byte   { return []byte(m) }
+func (m message) Payload() []byte { return []byte(m) }
+
+func TestSetup(t *testing.T) {
+	w, endpoint := Setup(t,
+		redisdb.WithChannel("redistest"),
+		redisdb.WithDeliveryMode(redisdb.List),
+	)
+	assert.NotEmpty(t, endpoint)
+
+	m := job.NewMessage(message("foo"))
+	require.NoError(t, w.Queue(&m))
+	msg, err := w.Fetch(context.Background(), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(msg.Payload()))
+	assert.NoError(t, w.Run(context.Background(), msg))
+}
@@ -0,0 +1,157 @@
+package redisdb
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// workersKey indexes the running workers by their last heartbeat.
+	workersKey = "redisdb:workers"
+	// workerHeartbeatInterval is how often workers refresh their entry.
+	workerHeartbeatInterval = 10 * time.Second
+	// workerTTL is how long the entry of a worker outlives its last
+	// heartbeat.
+	workerTTL = 3 * workerHeartbeatInterval
+)
+
+func workerKey(id string) string {
+	return "redisdb:worker:" + id
+}
+
+// WorkerInfo describes a running worker, as returned by ListWorkers.
+type WorkerInfo struct {
+	// ID identifies the worker instance, workers of a process may share
+	// their consumer name.
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Hostname string   `json:"hostname"`
+	PID      int      `json:"pid"`
+	Mode     string   `json:"mode"`
+	Channels []string `json:"channels"`
+	// Processed counts the jobs run since the worker started.
+	Processed int64 `json:"processed"`
+	// Active is the number of messages the worker is processing.
+	Active    int       `json:"active"`
+	StartedAt time.Time `json:"started_at"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// heartbeat writes the entry of the worker in the registry.
+func (w *Worker) heartbeat(ctx context.Context) error {
+	host, _ := os.Hostname()
+	now := time.Now()
+	key := w.opts.key(workerKey(w.id))
+	_, err := w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"name", w.opts.consumerName,
+			"hostname", host,
+			"pid", os.Getpid(),
+			"mode", w.opts.mode.String(),
+			"channels", strings.Join(w.opts.channels, ","),
+			"processed", atomic.LoadInt64(&w.processed),
+			"active", w.inflight(),
+			"started_at", w.startedAt.UnixMilli(),
+			"last_seen", now.UnixMilli(),
+		)
+		pipe.Expire(ctx, key, workerTTL)
+		pipe.ZAdd(ctx, w.opts.key(workersKey), redis.Z{
+			Score:  float64(now.UnixMilli()),
+			Member: w.id,
+		})
+		return nil
+	})
+	return err
+}
+
+// unregister removes the worker from the registry.
+func (w *Worker) unregister(ctx context.Context) error {
+	_, err := w.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, w.opts.key(workerKey(w.id)))
+		pipe.ZRem(ctx, w.opts.key(workersKey), w.id)
+		return nil
+	})
+	return err
+}
+
+// watchHeartbeat refreshes the entry of the worker until it stops.
+func (w *Worker) watchHeartbeat() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(workerHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.heartbeat(context.Background()); err != nil {
+				w.log.Error("worker heartbeat failed", "consumer", w.opts.consumerName, "error", err)
+			}
+			if err := w.flushThroughput(context.Background()); err != nil {
+				w.log.Error("failed to write throughput", "error", err)
+			}
+		}
+	}
+}
+
+// ListWorkers returns the workers that sent a heartbeat recently, whatever
+// their channels, sorted by ID. Workers that stopped without shutting down
+// are dropped from the registry.
+func (w *Worker) ListWorkers(ctx context.Context) ([]WorkerInfo, error) {
+	expired := strconv.FormatInt(time.Now().Add(-workerTTL).UnixMilli(), 10)
+	if err := w.rdb.ZRemRangeByScore(ctx, w.opts.key(workersKey), "-inf", "("+expired).Err(); err != nil {
+		return nil, err
+	}
+	ids, err := w.rdb.ZRange(ctx, w.opts.key(workersKey), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, 0, len(ids))
+	_, err = w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			cmds = append(cmds, pipe.HGetAll(ctx, w.opts.key(workerKey(id))))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	workers := make([]WorkerInfo, 0, len(ids))
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			continue
+		}
+		info := WorkerInfo{
+			ID:       ids[i],
+			Name:     fields["name"],
+			Hostname: fields["hostname"],
+			Mode:     fields["mode"],
+			Channels: strings.Split(fields["channels"], ","),
+		}
+		info.PID, _ = strconv.Atoi(fields["pid"])
+		info.Processed, _ = strconv.ParseInt(fields["processed"], 10, 64)
+		info.Active, _ = strconv.Atoi(fields["active"])
+		info.StartedAt = parseMillis(fields["started_at"])
+		info.LastSeen = parseMillis(fields["last_seen"])
+		workers = append(workers, info)
+	}
+	slices.SortFunc(workers, func(a, b WorkerInfo) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return workers, nil
+}
+
+func parseMillis(s string) time.Time {
+	ms, _ := strconv.ParseInt(s, 10, 64)
+	return time.UnixMilli(ms)
+}
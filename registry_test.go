@@ -0,0 +1,58 @@
+package redisdb
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestListWorkers(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w1 := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("registry-a"),
+		WithDeliveryMode(List),
+		WithConsumerName("one"),
+	)
+	w2 := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("registry-b", "registry-c"),
+		WithDeliveryMode(Stream),
+		WithConsumerName("two"),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	require.NoError(t, w1.Queue(&m))
+	task, err := w1.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w1.Run(ctx, task))
+	require.NoError(t, w1.heartbeat(ctx))
+
+	workers, err := w2.ListWorkers(ctx)
+	require.NoError(t, err)
+	require.Len(t, workers, 2)
+	assert.Equal(t, "one", workers[0].Name)
+	assert.Equal(t, "list", workers[0].Mode)
+	assert.Equal(t, []string{"registry-a"}, workers[0].Channels)
+	assert.Equal(t, int64(1), workers[0].Processed)
+	assert.Equal(t, os.Getpid(), workers[0].PID)
+	assert.WithinDuration(t, time.Now(), workers[0].LastSeen, time.Second)
+	assert.Equal(t, "two", workers[1].Name)
+	assert.Equal(t, []string{"registry-b", "registry-c"}, workers[1].Channels)
+
+	assert.NoError(t, w1.Shutdown())
+	workers, err = w2.ListWorkers(ctx)
+	require.NoError(t, err)
+	require.Len(t, workers, 1)
+	assert.Equal(t, "two", workers[0].Name)
+	assert.NoError(t, w2.Shutdown())
+}
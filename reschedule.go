@@ -0,0 +1,46 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Reschedule changes when a delayed job of the consumed channels is
+// delivered. Only the jobs retried later, with Retry or a retry delay, wait
+// in the delayed set. The score of its entry in the delayed set is changed
+// in place, so the job is never missing nor delivered twice meanwhile. Jobs
+// are identified like for Cancel, and found by their entry in the index of
+// the delayed set. It returns ErrJobNotFound when the job is not delayed,
+// including when it was delivered before it could be moved.
+func (w *Worker) Reschedule(ctx context.Context, jobID string, at time.Time) error {
+	found := false
+	for _, channel := range w.opts.channels {
+		member, err := w.delayedMember(ctx, channel, jobID)
+		if err != nil {
+			return err
+		}
+		if member == "" {
+			continue
+		}
+		// XX only updates the entry, it is not added back once moved
+		_, err = w.rdb.ZAddArgs(ctx, delayedKey(channel), redis.ZAddArgs{
+			XX:      true,
+			Members: []redis.Z{{Score: float64(at.UnixMilli()), Member: member}},
+		}).Result()
+		if err != nil {
+			return err
+		}
+		if err := w.rdb.ZScore(ctx, delayedKey(channel), member).Err(); err == nil {
+			found = true
+		} else if !errors.Is(err, redis.Nil) {
+			return err
+		}
+	}
+	if !found {
+		return ErrJobNotFound
+	}
+	return nil
+}
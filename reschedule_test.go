@@ -0,0 +1,49 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestReschedule(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	channel := "reschedule"
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel(channel),
+		WithDeliveryMode(List),
+	)
+	defer w.Shutdown()
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	data, err := w.encode(&m, envelope{ID: "job-1"})
+	require.NoError(t, err)
+	require.NoError(t, w.schedule(ctx, channel, data, time.Now().Add(time.Hour)))
+
+	assert.ErrorIs(t, w.Reschedule(ctx, "job-2", time.Now()), ErrJobNotFound)
+
+	at := time.Now().Add(-time.Second)
+	require.NoError(t, w.Reschedule(ctx, "job-1", at))
+	entries, err := w.Redis().ZRangeWithScores(ctx, delayedKey(channel), 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, float64(at.UnixMilli()), entries[0].Score)
+
+	require.NoError(t, w.moveDelayed(ctx))
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Run(ctx, task))
+
+	// the job is no longer delayed, nor indexed
+	assert.ErrorIs(t, w.Reschedule(ctx, "job-1", time.Now()), ErrJobNotFound)
+	assert.Equal(t, int64(0), w.Redis().Exists(ctx, delayedIDsKey(channel)).Val())
+}
@@ -0,0 +1,49 @@
+package redisdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+)
+
+// Retry is returned by a handler to have its message delivered again after
+// the given delay, instead of being retried according to the job retry
+// settings. It suits APIs answering with a Retry-After.
+//
+//	return redisdb.Retry{After: 10 * time.Minute}
+type Retry struct {
+	After time.Duration
+	// Err optionally records why the job is retried.
+	Err error
+}
+
+func (r Retry) Error() string {
+	if r.Err != nil {
+		return fmt.Sprintf("redisdb: retry after %s: %v", r.After, r.Err)
+	}
+	return fmt.Sprintf("redisdb: retry after %s", r.After)
+}
+
+func (r Retry) Unwrap() error {
+	return r.Err
+}
+
+// retryLater delivers the failed job of m again, with one retry less,
+// after the delay returned by the func of WithRetryDelayFunc, randomized
+// by the jitter of WithRetryJitter. A negative delay leaves the retry to
+// the queue.
+func (w *Worker) retryLater(ack *Acknowledger, m *job.Message, attempt int, err error) error {
+	delay := w.opts.retryDelay(attempt, err, m)
+	if delay < 0 {
+		return nil
+	}
+	delay = w.opts.jitter.apply(delay)
+	v, ok := w.deliveries.Load(m)
+	if !ok {
+		return nil
+	}
+	n := m.RetryCount - 1
+	v.(*delivery).retryCount = &n
+	return ack.Nack(delay)
+}
@@ -0,0 +1,94 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestRetryAfter(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	attempts := 0
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("retry-after"),
+		WithDeliveryMode(Stream),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			attempts++
+			return Retry{After: time.Hour, Err: errors.New("429 too many requests")}
+		}),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{
+		RetryCount: job.Int64(3),
+	})
+	assert.NoError(t, w.Queue(&m))
+
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, attempts)
+
+	// the message waits in the delayed set for an hour
+	due := w.Redis().ZRangeWithScores(ctx, delayedKey("retry-after"), 0, -1).Val()
+	assert.Len(t, due, 1)
+	assert.InDelta(t, time.Now().Add(time.Hour).UnixMilli(), due[0].Score, float64(time.Minute.Milliseconds()))
+	assert.Equal(t, int64(0), w.Redis().XPending(ctx, "retry-after", defaultConsumerGroup).Val().Count)
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestRetryDelayFunc(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	errRateLimited := errors.New("429 too many requests")
+	var attempts []int
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("retry-delay-func"),
+		WithDeliveryMode(List),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			return errRateLimited
+		}),
+		WithRetryDelayFunc(func(attempt int, err error, msg core.QueuedMessage) time.Duration {
+			attempts = append(attempts, attempt)
+			if errors.Is(err, errRateLimited) {
+				return time.Hour
+			}
+			return -1
+		}),
+	)
+	defer w.Shutdown()
+
+	m := job.NewMessage(mockMessage{Message: "foo"}, job.AllowOption{
+		RetryCount: job.Int64(3),
+	})
+	assert.NoError(t, w.Queue(&m))
+
+	n, err := w.DrainOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int{1}, attempts)
+
+	// the message waits in the delayed set with one retry less
+	due := w.Redis().ZRangeWithScores(ctx, delayedKey("retry-delay-func"), 0, -1).Val()
+	assert.Len(t, due, 1)
+	assert.InDelta(t, time.Now().Add(time.Hour).UnixMilli(), due[0].Score, float64(time.Minute.Milliseconds()))
+	_, data, _ := strings.Cut(due[0].Member.(string), delayedSeparator)
+	retried, env, err := w.decode([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), retried.RetryCount)
+	assert.Equal(t, 1, env.Attempts)
+}
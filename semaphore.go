@@ -0,0 +1,190 @@
+package redisdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// semaphorePollInterval is how often a full semaphore is tried again
+	// unless WithBackoff is set.
+	semaphorePollInterval = 50 * time.Millisecond
+	// semaphoreLease bounds how long a slot is held when the job context
+	// has no deadline, so slots of crashed workers are eventually freed.
+	semaphoreLease = time.Hour
+	// lockAuditInterval is how often the expired leases of the semaphores
+	// and locks used by the worker are released.
+	lockAuditInterval = time.Minute
+)
+
+// acquireScript takes a slot of the semaphore stored in the sorted set
+// KEYS[1] when fewer than ARGV[1] slots are held. Members are holder tokens
+// scored by the time their lease expires, expired leases are dropped first.
+// It returns whether the slot was taken and how many leases expired.
+var acquireScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local expired = redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[1]) then
+  redis.call('ZADD', KEYS[1], now + tonumber(ARGV[3]), ARGV[2])
+  return {1, expired}
+end
+return {0, expired}
+`)
+
+// expireScript drops the expired leases of the semaphore KEYS[1] and
+// returns how many there were.
+var expireScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+return redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+`)
+
+// semaphore limits how many holders across all workers may run at once.
+type semaphore struct {
+	rdb     redis.Cmdable
+	key     string
+	limit   int
+	backoff Backoff
+	// expired is called with the number of leases that expired before
+	// their holder released them.
+	expired func(n int64)
+}
+
+func (w *Worker) newSemaphore(key string, limit int) *semaphore {
+	return &semaphore{
+		rdb:     w.rdb,
+		key:     key,
+		limit:   limit,
+		backoff: w.claimBackoff(),
+		expired: func(n int64) {
+			w.lockExpired(key, n)
+		},
+	}
+}
+
+// extendScript moves the lease expiry of the holder ARGV[1] of the
+// semaphore KEYS[1] to ARGV[2] milliseconds from now, if it still holds it.
+var extendScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+return redis.call('ZADD', KEYS[1], 'XX', 'CH', now + tonumber(ARGV[2]), ARGV[1])
+`)
+
+// acquire waits for a free slot and returns the token to release it with.
+// The slot is leased for the given duration.
+func (s *semaphore) acquire(ctx context.Context, lease time.Duration) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := acquireScript.Run(ctx, s.rdb, []string{s.key}, s.limit, token, lease.Milliseconds()).Int64Slice()
+		if err != nil {
+			return "", err
+		}
+		s.reportExpired(res[1])
+		if res[0] == 1 {
+			return token, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(s.backoff.Delay(attempt)):
+		}
+	}
+}
+
+// extend renews the lease of a held slot.
+func (s *semaphore) extend(ctx context.Context, token string, lease time.Duration) error {
+	return extendScript.Run(ctx, s.rdb, []string{s.key}, token, lease.Milliseconds()).Err()
+}
+
+// release frees a held slot. It returns ErrLeaseExpired when the lease
+// had already expired, the slot may then have been given to another
+// holder in the meantime.
+func (s *semaphore) release(ctx context.Context, token string) error {
+	n, err := s.rdb.ZRem(ctx, s.key, token).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseExpired
+	}
+	return nil
+}
+
+// expire drops the expired leases of the semaphore.
+func (s *semaphore) expire(ctx context.Context) error {
+	n, err := expireScript.Run(ctx, s.rdb, []string{s.key}).Int64()
+	if err != nil {
+		return err
+	}
+	s.reportExpired(n)
+	return nil
+}
+
+func (s *semaphore) reportExpired(n int64) {
+	if n > 0 && s.expired != nil {
+		s.expired(n)
+	}
+}
+
+// acquireSlot takes a slot of the semaphore key for the job running with
+// ctx, and returns the func releasing it.
+func (w *Worker) acquireSlot(ctx context.Context, ack *Acknowledger, key string, limit int) (func(), error) {
+	sem := w.newSemaphore(key, limit)
+	token, err := sem.acquire(ctx, leaseFor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	ack.slots = append(ack.slots, heldSlot{sem: sem, token: token})
+
+	return func() {
+		if err := sem.release(context.Background(), token); err != nil {
+			w.log.Error("failed to release concurrency slot", "slot", key, "error", err)
+		}
+	}, nil
+}
+
+// typeConcurrencyLimit returns the type of the job of task and how many
+// jobs of that type may run at once, 0 means no limit.
+func (w *Worker) typeConcurrencyLimit(task core.TaskMessage) (string, int) {
+	if w.opts.jobType == nil || len(w.opts.typeConcurrency) == 0 {
+		return "", 0
+	}
+	jobType := w.opts.jobType(task)
+	return jobType, w.opts.typeConcurrency[jobType]
+}
+
+func typeConcurrencyKey(jobType string) string {
+	return "redisdb:type:" + jobType + ":concurrency"
+}
+
+// leaseFor returns how long a slot taken for a job running with ctx is held.
+func leaseFor(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return semaphoreLease
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func concurrencyKey(channel string) string {
+	return channel + ":concurrency"
+}
@@ -0,0 +1,149 @@
+package redisdb
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestMaxConcurrency(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var running, peak int32
+	runFunc := func(ctx context.Context, m core.TaskMessage) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	var workers []*Worker
+	for i := 0; i < 2; i++ {
+		workers = append(workers, NewWorker(
+			WithAddr(endpoint),
+			WithChannel("concurrency"),
+			WithMaxConcurrency(2),
+			WithRunFunc(runFunc),
+		))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+			m := job.NewMessage(mockMessage{Message: "foo"})
+			assert.NoError(t, w.Run(ctx, &m))
+		}(workers[i%2])
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+	// all the slots are released
+	assert.Equal(t, int64(0), workers[0].Redis().ZCard(ctx, concurrencyKey("concurrency")).Val())
+	for _, w := range workers {
+		assert.NoError(t, w.Shutdown())
+	}
+}
+
+func TestTypeConcurrency(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var reports, peak int32
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithJobType(func(m core.TaskMessage) string {
+			jobType, _, _ := strings.Cut(string(m.Payload()), ":")
+			return jobType
+		}),
+		WithTypeConcurrency("report", 1),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			if !strings.HasPrefix(string(m.Payload()), "report:") {
+				return nil
+			}
+			n := atomic.AddInt32(&reports, 1)
+			if n > atomic.LoadInt32(&peak) {
+				atomic.StoreInt32(&peak, n)
+			}
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&reports, -1)
+			return nil
+		}),
+	)
+
+	var wg sync.WaitGroup
+	for _, v := range []string{"report:1", "email:1", "report:2", "email:2", "report:3"} {
+		wg.Add(1)
+		go func(v string) {
+			defer wg.Done()
+			m := job.NewMessage(mockMessage{Message: v})
+			assert.NoError(t, w.Run(ctx, &m))
+		}(v)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&peak))
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestSemaphoreLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(WithAddr(endpoint))
+	sem := &semaphore{rdb: w.Redis(), key: "lease", limit: 1, backoff: w.claimBackoff()}
+
+	// a holder that never releases its slot
+	leaseCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err := sem.acquire(leaseCtx, leaseFor(leaseCtx))
+	assert.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	token, err := sem.acquire(waitCtx, leaseFor(waitCtx))
+	assert.NoError(t, err)
+	assert.NoError(t, sem.release(ctx, token))
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestSemaphoreScriptFlush(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(WithAddr(endpoint))
+	sem := &semaphore{rdb: w.Redis(), key: "flush", limit: 1, backoff: w.claimBackoff()}
+
+	token, err := sem.acquire(ctx, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, sem.release(ctx, token))
+
+	// scripts are loaded again after the cache is flushed
+	assert.NoError(t, w.Redis().ScriptFlush(ctx).Err())
+	token, err = sem.acquire(ctx, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, sem.extend(ctx, token, time.Minute))
+	assert.NoError(t, sem.release(ctx, token))
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,73 @@
+package redisdb
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ShardKeyHeader is the metadata header whose value picks the shard of a
+// job with WithShards, so that the jobs of a key share a shard. Jobs
+// without it are spread round-robin.
+const ShardKeyHeader = "shard-key"
+
+// shardKey returns the key of a shard of channel. The hash tag puts every
+// shard, with the keys derived from it, in a slot of its own.
+func shardKey(channel string, shard int) string {
+	return channel + ":{" + strconv.Itoa(shard) + "}"
+}
+
+// withShards replaces the channels with their shards. Only the List and
+// Stream modes are sharded.
+func (o *options) withShards() {
+	switch o.mode {
+	case List, Stream:
+	default:
+		o.shards = 0
+	}
+	if o.shards < 2 {
+		return
+	}
+
+	channels := make([]string, 0, len(o.channels)*o.shards)
+	for _, channel := range o.channels {
+		for i := 0; i < o.shards; i++ {
+			channels = append(channels, shardKey(channel, i))
+		}
+	}
+	o.channels = channels
+}
+
+// shardName returns the channel a shard belongs to.
+func (o *options) shardName(channel string) string {
+	if o.shards < 2 || !strings.HasSuffix(channel, "}") {
+		return channel
+	}
+	if i := strings.LastIndex(channel, ":{"); i >= 0 {
+		return channel[:i]
+	}
+	return channel
+}
+
+// shard returns the shard of the first channel a job is queued to.
+func (w *Worker) shard(env envelope) string {
+	if w.opts.shards < 2 {
+		return w.opts.channels[0]
+	}
+	return w.opts.channels[w.shardIndex(env)]
+}
+
+// shardIndex picks the shard of a job, from its ShardKeyHeader or
+// round-robin.
+func (w *Worker) shardIndex(env envelope) int {
+	var i uint32
+	if key := env.Headers[ShardKeyHeader]; key != "" {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+		i = h.Sum32()
+	} else {
+		i = atomic.AddUint32(&w.nextShard, 1)
+	}
+	return int(i % uint32(w.opts.shards))
+}
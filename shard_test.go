@@ -0,0 +1,68 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestShards(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var channels []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("sharded"),
+		WithDeliveryMode(List),
+		WithShards(4),
+		WithBlockTime(100*time.Millisecond),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			channels = append(channels, ChannelFromContext(ctx))
+			return nil
+		}),
+	)
+	rdb := w.Redis()
+	assert.Equal(t, []string{"sharded:{0}", "sharded:{1}", "sharded:{2}", "sharded:{3}"}, w.Topology().Channels)
+
+	// jobs are spread round-robin
+	for i := 0; i < 8; i++ {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		require.NoError(t, w.Queue(&m))
+	}
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, int64(2), rdb.LLen(ctx, shardKey("sharded", i)).Val())
+	}
+
+	// or by their shard key
+	kctx := ContextWithMetadata(ctx, Metadata{Headers: map[string]string{ShardKeyHeader: "user-1"}})
+	for i := 0; i < 4; i++ {
+		m := job.NewMessage(mockMessage{Message: "foo"})
+		_, err := w.QueueWithID(kctx, &m)
+		require.NoError(t, err)
+	}
+	var lens []int64
+	for i := 0; i < 4; i++ {
+		lens = append(lens, rdb.LLen(ctx, shardKey("sharded", i)).Val())
+	}
+	assert.Contains(t, lens, int64(6))
+
+	// workers read all the shards and see the channel name
+	for i := 0; i < 12; i++ {
+		msg, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		assert.NoError(t, w.Run(ctx, msg))
+	}
+	assert.Len(t, channels, 12)
+	for _, channel := range channels {
+		assert.Equal(t, "sharded", channel)
+	}
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,344 @@
+package redisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ broker = (*sidekiqBroker)(nil)
+
+// SidekiqClassHeader is the metadata header holding the worker class of
+// Sidekiq jobs, see ContextWithMetadata. It is required to queue jobs in
+// Sidekiq mode.
+const SidekiqClassHeader = "sidekiq-class"
+
+// ErrNoSidekiqClass is returned when a job is queued in Sidekiq mode
+// without the SidekiqClassHeader header.
+var ErrNoSidekiqClass = errors.New("redisdb: sidekiq jobs need a " + SidekiqClassHeader + " header")
+
+const (
+	sidekiqQueuesKey   = "queues"
+	sidekiqScheduleKey = "schedule"
+	sidekiqRetryKey    = "retry"
+	sidekiqDeadKey     = "dead"
+	// sidekiqDefaultRetry is the retry count of jobs queued with
+	// "retry": true.
+	sidekiqDefaultRetry = 25
+	// sidekiqTimeout applies to the Sidekiq jobs run by the worker, which
+	// have no timeout of their own.
+	sidekiqTimeout   = 30 * time.Minute
+	sidekiqDeadMax   = 10000
+	sidekiqDeadTTL   = 180 * 24 * time.Hour
+	sidekiqPollBatch = 100
+)
+
+func sidekiqQueueKey(queue string) string {
+	return "queue:" + queue
+}
+
+// sidekiqForwardScript pushes up to ARGV[2] jobs of the schedule or retry
+// set KEYS[1] that are due at ARGV[1] to their queue, like the scheduler of
+// Sidekiq does. Its first line lets Dragonfly touch the queue keys.
+var sidekiqForwardScript = redis.NewScript(`--!df flags=allow-undeclared-keys
+local jobs = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, job in ipairs(jobs) do
+  redis.call('ZREM', KEYS[1], job)
+  local queue = cjson.decode(job)['queue']
+  redis.call('SADD', 'queues', queue)
+  redis.call('LPUSH', 'queue:' .. queue, job)
+end
+return #jobs
+`)
+
+// sidekiqJob holds the fields of a Sidekiq job used by the worker, the
+// others are kept as they are.
+type sidekiqJob struct {
+	Class      string          `json:"class"`
+	Args       json.RawMessage `json:"args"`
+	Queue      string          `json:"queue"`
+	JID        string          `json:"jid"`
+	Retry      json.RawMessage `json:"retry,omitempty"`
+	RetryCount *int            `json:"retry_count,omitempty"`
+	CreatedAt  float64         `json:"created_at,omitempty"`
+}
+
+// retries returns the retry count of the job, from its retry field which
+// is a boolean or a number.
+func (j *sidekiqJob) retries() int64 {
+	switch s := string(j.Retry); s {
+	case "", "false":
+		return 0
+	case "true":
+		return sidekiqDefaultRetry
+	default:
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return max(n, 0)
+	}
+}
+
+// sidekiqArgs returns the args of the Sidekiq job of a payload: the
+// payload itself when it is a JSON array, else its single argument.
+func sidekiqArgs(payload []byte) json.RawMessage {
+	if json.Valid(payload) {
+		if bytes.HasPrefix(bytes.TrimSpace(payload), []byte("[")) {
+			return payload
+		}
+		return append(append([]byte("["), payload...), ']')
+	}
+	b, _ := json.Marshal([]string{string(payload)})
+	return b
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
+// sidekiqBroker pushes jobs in the Sidekiq format onto the queue:<name>
+// lists, so Ruby Sidekiq processes run them, and consumes them reliably
+// through the processing lists of List mode. The job args are the
+// payload of the job.
+type sidekiqBroker struct {
+	w    *Worker
+	rdb  redis.Cmdable
+	list *listBroker
+
+	// inflight maps the deliveries handed out to the list deliveries they
+	// come from, which hold the original job.
+	mu       sync.Mutex
+	inflight map[*delivery]*delivery
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newSidekiqBroker(ctx context.Context, w *Worker) (*sidekiqBroker, error) {
+	keys := make([]string, 0, len(w.opts.channels))
+	for _, channel := range w.opts.channels {
+		keys = append(keys, sidekiqQueueKey(channel))
+	}
+	list, err := newListBroker(ctx, w, keys)
+	if err != nil {
+		return nil, err
+	}
+	b := &sidekiqBroker{
+		w:        w,
+		rdb:      w.rdb,
+		list:     list,
+		inflight: make(map[*delivery]*delivery),
+		stop:     make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(delayedPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				if err := b.forward(context.Background()); err != nil {
+					w.log.Error("failed to forward sidekiq jobs", "error", err)
+				}
+			}
+		}
+	}()
+
+	return b, nil
+}
+
+// forward moves the due scheduled and retried jobs to their queue.
+func (b *sidekiqBroker) forward(ctx context.Context) error {
+	now := strconv.FormatFloat(unixSeconds(time.Now()), 'f', -1, 64)
+	for _, key := range []string{sidekiqScheduleKey, sidekiqRetryKey} {
+		err := sidekiqForwardScript.Run(ctx, b.rdb, []string{key}, now, sidekiqPollBatch).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// push queues the job of data as a Sidekiq job of the class set in its
+// headers.
+func (b *sidekiqBroker) push(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error {
+	m, env, err := b.w.decode(data)
+	if err != nil {
+		return err
+	}
+	class := env.Headers[SidekiqClassHeader]
+	if class == "" {
+		return ErrNoSidekiqClass
+	}
+	jid := env.ID
+	if jid == "" {
+		jid = ulid.Make().String()
+	}
+	var retry any = false
+	if m.RetryCount > 0 {
+		retry = m.RetryCount
+	}
+	now := unixSeconds(time.Now())
+	payload, err := json.Marshal(map[string]any{
+		"class":       class,
+		"args":        sidekiqArgs(m.Body),
+		"queue":       channel,
+		"jid":         jid,
+		"retry":       retry,
+		"created_at":  now,
+		"enqueued_at": now,
+	})
+	if err != nil {
+		return err
+	}
+
+	enqueue := func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, sidekiqQueuesKey, channel)
+		pipe.LPush(ctx, sidekiqQueueKey(channel), payload)
+		return nil
+	}
+	if pipe, ok := rdb.(redis.Pipeliner); ok {
+		return enqueue(pipe)
+	}
+	_, err = rdb.TxPipelined(ctx, enqueue)
+	return err
+}
+
+// setSidekiqField sets a field of a serialized Sidekiq job.
+func setSidekiqField(data []byte, name string, value any) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[name] = v
+	return json.Marshal(fields)
+}
+
+func (b *sidekiqBroker) pop(ctx context.Context, timeout time.Duration) (*delivery, error) {
+	src, err := b.list.pop(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+	d := &delivery{channel: strings.TrimPrefix(src.channel, "queue:")}
+
+	var j sidekiqJob
+	if err := json.Unmarshal(src.data, &j); err != nil {
+		// the worker rejects the delivery, since the data cannot be decoded
+		d.data = src.data
+	} else {
+		m := &job.Message{Body: j.Args, RetryCount: j.retries(), Timeout: sidekiqTimeout}
+		env := envelope{
+			Version: envelopeVersion,
+			ID:      j.JID,
+			Headers: map[string]string{SidekiqClassHeader: j.Class},
+		}
+		if j.CreatedAt > 0 {
+			env.EnqueuedAt = time.UnixMicro(int64(j.CreatedAt * 1e6))
+		}
+		if j.RetryCount != nil {
+			// retry_count is set when the job first fails
+			env.Attempts = *j.RetryCount + 1
+			m.RetryCount = max(m.RetryCount-int64(env.Attempts), 0)
+		}
+		if d.data, err = b.w.encode(m, env); err != nil {
+			return nil, err
+		}
+	}
+
+	b.mu.Lock()
+	b.inflight[d] = src
+	b.mu.Unlock()
+	return d, nil
+}
+
+// source stops tracking a delivery and returns the list delivery it
+// comes from.
+func (b *sidekiqBroker) source(d *delivery) *delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	src, ok := b.inflight[d]
+	if !ok {
+		src = &delivery{channel: sidekiqQueueKey(d.channel), data: d.data}
+	}
+	delete(b.inflight, d)
+	return src
+}
+
+func (b *sidekiqBroker) ack(ctx context.Context, d *delivery) error {
+	return b.list.ack(ctx, b.source(d))
+}
+
+// reject moves a job that failed all its attempts to the Sidekiq dead set.
+func (b *sidekiqBroker) reject(ctx context.Context, d *delivery) error {
+	src := b.source(d)
+	now := time.Now()
+	data, err := setSidekiqField(src.data, "failed_at", unixSeconds(now))
+	if err != nil {
+		// not a Sidekiq job, keep it as it is
+		data = src.data
+	}
+	_, err = b.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, processingKey(src.channel, b.list.consumer), 1, src.data)
+		pipe.ZAdd(ctx, sidekiqDeadKey, redis.Z{Score: unixSeconds(now), Member: data})
+		pipe.ZRemRangeByScore(ctx, sidekiqDeadKey, "-inf",
+			strconv.FormatFloat(unixSeconds(now.Add(-sidekiqDeadTTL)), 'f', -1, 64))
+		pipe.ZRemRangeByRank(ctx, sidekiqDeadKey, 0, -sidekiqDeadMax-1)
+		return nil
+	})
+	return err
+}
+
+// requeue hands the original job back to its queue.
+func (b *sidekiqBroker) requeue(ctx context.Context, d *delivery, data []byte) error {
+	src := b.source(d)
+	return b.list.requeue(ctx, src, src.data)
+}
+
+// retry moves the job to the Sidekiq retry set, with its runs counted in
+// retry_count.
+func (b *sidekiqBroker) retry(ctx context.Context, d *delivery, at time.Time) error {
+	src := b.source(d)
+	data := src.data
+	var j sidekiqJob
+	if err := json.Unmarshal(src.data, &j); err == nil {
+		count := int(atomic.LoadInt32(&d.attempts)) - 1
+		if j.RetryCount != nil {
+			count += *j.RetryCount + 1
+		}
+		if v, err := setSidekiqField(data, "retry_count", count); err == nil {
+			data = v
+		}
+	}
+	_, err := b.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, processingKey(src.channel, b.list.consumer), 1, src.data)
+		pipe.ZAdd(ctx, sidekiqRetryKey, redis.Z{Score: unixSeconds(at), Member: data})
+		return nil
+	})
+	return err
+}
+
+func (b *sidekiqBroker) touch(ctx context.Context, d *delivery) error {
+	return nil
+}
+
+func (b *sidekiqBroker) close() error {
+	close(b.stop)
+	b.wg.Wait()
+	return b.list.close()
+}
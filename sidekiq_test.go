@@ -0,0 +1,119 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/core"
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestSidekiqArgs(t *testing.T) {
+	assert.JSONEq(t, `[1,"a"]`, string(sidekiqArgs([]byte(`[1,"a"]`))))
+	assert.JSONEq(t, `[{"id":1}]`, string(sidekiqArgs([]byte(`{"id":1}`))))
+	assert.JSONEq(t, `["plain text"]`, string(sidekiqArgs([]byte(`plain text`))))
+}
+
+func TestSidekiq(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var classes []string
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("default"),
+		WithDeliveryMode(Sidekiq),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			md, _ := MetadataFromContext(ctx)
+			classes = append(classes, md.Headers[SidekiqClassHeader])
+			if string(m.Payload()) == `["fail"]` {
+				return errors.New("failed")
+			}
+			return nil
+		}),
+	)
+	rdb := w.Redis()
+
+	m := job.NewMessage(mockMessage{Message: `[1,2]`}, job.AllowOption{RetryCount: job.Int64(3)})
+	assert.ErrorIs(t, w.Queue(&m), ErrNoSidekiqClass)
+
+	// jobs are pushed in the Sidekiq format
+	qctx := ContextWithMetadata(ctx, Metadata{Headers: map[string]string{SidekiqClassHeader: "HardJob"}})
+	id, err := w.QueueWithID(qctx, &m)
+	require.NoError(t, err)
+	assert.True(t, rdb.SIsMember(ctx, "queues", "default").Val())
+	jobs := rdb.LRange(ctx, "queue:default", 0, -1).Val()
+	require.Len(t, jobs, 1)
+	var pushed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(jobs[0]), &pushed))
+	assert.Equal(t, "HardJob", pushed["class"])
+	assert.Equal(t, []any{1.0, 2.0}, pushed["args"])
+	assert.Equal(t, "default", pushed["queue"])
+	assert.Equal(t, id, pushed["jid"])
+	assert.Equal(t, 3.0, pushed["retry"])
+
+	// and jobs pushed by Sidekiq clients are consumed
+	rdb.LPush(ctx, "queue:default", `{"class":"MailJob","args":["fail"],"queue":"default","jid":"abc","retry":false}`)
+	for i := 0; i < 2; i++ {
+		msg, err := w.Fetch(ctx, time.Second)
+		require.NoError(t, err)
+		_ = w.Run(ctx, msg)
+	}
+	assert.Equal(t, []string{"HardJob", "MailJob"}, classes)
+	assert.Zero(t, rdb.LLen(ctx, "queue:default").Val())
+	assert.Zero(t, rdb.LLen(ctx, processingKey("queue:default", w.opts.consumerName)).Val())
+
+	dead := rdb.ZRange(ctx, "dead", 0, -1).Val()
+	require.Len(t, dead, 1)
+	var failed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(dead[0]), &failed))
+	assert.Equal(t, "abc", failed["jid"])
+	assert.Contains(t, failed, "failed_at")
+	assert.NoError(t, w.Shutdown())
+}
+
+func TestSidekiqRetry(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	var attempts []int
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("retries"),
+		WithDeliveryMode(Sidekiq),
+		WithRunFunc(func(ctx context.Context, m core.TaskMessage) error {
+			md, _ := MetadataFromContext(ctx)
+			attempts = append(attempts, md.Attempt)
+			return Retry{After: time.Second}
+		}),
+	)
+	rdb := w.Redis()
+
+	rdb.LPush(ctx, "queue:retries", `{"class":"MailJob","args":[],"queue":"retries","jid":"abc","retry":true}`)
+	msg, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, msg))
+
+	retried := rdb.ZRange(ctx, "retry", 0, -1).Val()
+	require.Len(t, retried, 1)
+	var j sidekiqJob
+	require.NoError(t, json.Unmarshal([]byte(retried[0]), &j))
+	require.NotNil(t, j.RetryCount)
+	assert.Equal(t, 0, *j.RetryCount)
+
+	// the job is pushed back to its queue once due
+	msg, err = w.Fetch(ctx, 3*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(sidekiqDefaultRetry-1), msg.(*job.Message).RetryCount)
+	assert.NoError(t, w.Run(ctx, msg))
+	assert.Equal(t, []int{1, 2}, attempts)
+	assert.NoError(t, w.Shutdown())
+}
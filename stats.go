@@ -0,0 +1,68 @@
+package redisdb
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the state of a worker and its channels, meant to
+// be served as JSON by status endpoints.
+type Stats struct {
+	Channels []ChannelStats `json:"channels"`
+	// Workers are the running workers of all channels, see ListWorkers.
+	Workers []WorkerInfo `json:"workers"`
+	// Processed counts the jobs run by this worker since it started.
+	Processed int64 `json:"processed"`
+	// Throughput is the average number of jobs per second run by this
+	// worker since it started.
+	Throughput float64       `json:"throughput"`
+	Uptime     time.Duration `json:"uptime"`
+}
+
+// ChannelStats describes the jobs of a channel.
+type ChannelStats struct {
+	Channel string `json:"channel"`
+	// Jobs is the number of jobs in each state, see Inspector.
+	Jobs map[JobState]int64 `json:"jobs"`
+	// OldestPendingAge is how long ago the next pending job was queued,
+	// zero when it has no ID.
+	OldestPendingAge time.Duration `json:"oldest_pending_age"`
+}
+
+// Stats returns a snapshot of the worker, its channels and the running
+// workers.
+func (w *Worker) Stats(ctx context.Context) (Stats, error) {
+	i := &Inspector{w: w}
+	counts, err := i.Counts(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	s := Stats{
+		Channels:  make([]ChannelStats, 0, len(counts)),
+		Processed: atomic.LoadInt64(&w.processed),
+		Uptime:    time.Since(w.startedAt),
+	}
+	if secs := s.Uptime.Seconds(); secs > 0 {
+		s.Throughput = float64(s.Processed) / secs
+	}
+
+	for _, c := range counts {
+		cs := ChannelStats{Channel: c.Channel, Jobs: c.Jobs}
+		pending, err := i.listPending(ctx, c.Channel, 1)
+		if err != nil {
+			return Stats{}, err
+		}
+		if len(pending) > 0 && !pending[0].EnqueuedAt.IsZero() {
+			cs.OldestPendingAge = time.Since(pending[0].EnqueuedAt)
+		}
+		s.Channels = append(s.Channels, cs)
+	}
+
+	s.Workers, err = w.ListWorkers(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	return s, nil
+}
@@ -0,0 +1,48 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("stats"),
+		WithDeliveryMode(List),
+	)
+
+	for _, v := range []string{"a", "b"} {
+		m := job.NewMessage(mockMessage{Message: v})
+		require.NoError(t, w.Queue(&m))
+	}
+	time.Sleep(50 * time.Millisecond)
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Run(ctx, task))
+
+	s, err := w.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), s.Processed)
+	assert.Greater(t, s.Throughput, float64(0))
+	require.Len(t, s.Channels, 1)
+	assert.Equal(t, int64(1), s.Channels[0].Jobs[JobPending])
+	assert.GreaterOrEqual(t, s.Channels[0].OldestPendingAge, 50*time.Millisecond)
+	require.Len(t, s.Workers, 1)
+
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"pending":1`)
+	assert.NoError(t, w.Shutdown())
+}
@@ -0,0 +1,344 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-queue/queue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ broker = (*streamBroker)(nil)
+
+const (
+	defaultConsumerGroup = "redisdb"
+	streamPayloadField   = "payload"
+	// streamReclaimBatch is how many entries of a stream are reclaimed at
+	// once.
+	streamReclaimBatch = 100
+)
+
+// streamBroker delivers messages through Redis streams read by a consumer group.
+type streamBroker struct {
+	rdb      redis.Cmdable
+	channels *channelSet
+	group    string
+	consumer string
+	// acks batches the acknowledgements, see WithAckFlushInterval.
+	acks   *ackBatcher
+	log    *slog.Logger
+	flavor ServerFlavor
+	// broadcast starts the groups at the end of the streams and caps
+	// them, see WithBroadcast.
+	broadcast bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	// XREADGROUP returns up to one entry per stream, the entries that are
+	// not handed out right away wait here for the next pop.
+	mu      sync.Mutex
+	pending []*delivery
+	// lastID is the last entry read from each stream, where the group is
+	// created again when it is lost.
+	lastID map[string]string
+}
+
+func newStreamBroker(ctx context.Context, w *Worker) (*streamBroker, error) {
+	b := &streamBroker{
+		rdb:       w.rdb,
+		channels:  newChannelSet(w.opts.channels, w.opts.channelStrategy, &w.paused),
+		group:     w.opts.consumerGroup,
+		consumer:  w.opts.consumerName,
+		log:       w.log,
+		flavor:    w.opts.flavor,
+		lastID:    make(map[string]string),
+		broadcast: w.opts.broadcast,
+		stop:      make(chan struct{}),
+	}
+	if w.opts.ackFlushInterval > 0 || w.opts.ackBatchSize > 1 {
+		b.acks = newAckBatcher(w, b.group)
+	}
+
+	if err := b.createGroups(ctx); err != nil {
+		return nil, err
+	}
+
+	if w.opts.reclaims() {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			ticker := time.NewTicker(heartbeatInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-b.stop:
+					return
+				case <-ticker.C:
+					if err := b.reclaim(context.Background()); err != nil {
+						b.log.Error("failed to reclaim stream entries", "consumer", b.consumer, "error", err)
+					}
+				}
+			}
+		}()
+	}
+
+	return b, nil
+}
+
+// createGroups creates the consumer group of the streams that lack it.
+// It starts after the last entry read from the stream, or from the
+// beginning so messages added before the group existed are delivered too.
+// Broadcast groups start from the end instead, except on retry streams.
+func (b *streamBroker) createGroups(ctx context.Context) error {
+	for _, channel := range b.channels.names {
+		b.mu.Lock()
+		start := b.lastID[channel]
+		b.mu.Unlock()
+		switch {
+		case start != "":
+		case b.broadcast && broadcastChannel(channel, b.consumer) == channel:
+			start = "$"
+		default:
+			start = "0"
+		}
+		err := b.rdb.XGroupCreateMkStream(ctx, channel, b.group, start).Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return err
+		}
+	}
+	return nil
+}
+
+// reclaim claims the entries that the other consumers of the group left
+// pending for longer than the consumer TTL, the next pops hand them out.
+// The entries of this consumer are being run and are left alone.
+func (b *streamBroker) reclaim(ctx context.Context) error {
+	for _, channel := range b.channels.names {
+		pending, err := staleEntries(ctx, b.rdb, b.flavor, channel, b.group, streamReclaimBatch)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(pending))
+		for _, p := range pending {
+			if p.Consumer != b.consumer {
+				ids = append(ids, p.ID)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		msgs, err := b.rdb.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   channel,
+			Group:    b.group,
+			Consumer: b.consumer,
+			MinIdle:  consumerTTL,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		deliveries := make([]*delivery, 0, len(msgs))
+		for _, msg := range msgs {
+			payload, _ := msg.Values[streamPayloadField].(string)
+			deliveries = append(deliveries, &delivery{channel: channel, id: msg.ID, data: []byte(payload)})
+		}
+		b.mu.Lock()
+		b.pending = append(b.pending, deliveries...)
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+func (b *streamBroker) push(ctx context.Context, rdb redis.Cmdable, channel string, data []byte) error {
+	args := &redis.XAddArgs{
+		Stream: channel,
+		Values: map[string]interface{}{streamPayloadField: data},
+	}
+	if b.broadcast {
+		args.MaxLen = broadcastMaxLen
+		args.Approx = true
+	}
+	return rdb.XAdd(ctx, args).Err()
+}
+
+func (b *streamBroker) pop(ctx context.Context, timeout time.Duration) (*delivery, error) {
+	b.mu.Lock()
+	if len(b.pending) > 0 {
+		d := b.pending[0]
+		b.pending = b.pending[1:]
+		b.mu.Unlock()
+		return d, nil
+	}
+	b.mu.Unlock()
+
+	channels := b.channels.next()
+	if len(channels) == 0 {
+		// every channel is paused
+		if timeout <= 0 {
+			timeout = pausePollInterval
+		}
+		select {
+		case <-b.stop:
+		case <-ctx.Done():
+		case <-time.After(timeout):
+		}
+		return nil, queue.ErrNoTaskInQueue
+	}
+	args := make([]string, 0, len(channels)*2)
+	args = append(args, channels...)
+	for range channels {
+		args = append(args, ">")
+	}
+
+	read := &redis.XReadGroupArgs{
+		Group:    b.group,
+		Consumer: b.consumer,
+		Streams:  args,
+		Count:    1,
+		Block:    timeout,
+	}
+	streams, err := b.rdb.XReadGroup(ctx, read).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOGROUP") {
+		// the group is gone, as when a failover promotes a replica that
+		// missed its creation: create it again and resume from the last
+		// entry read
+		b.log.Warn("consumer group lost, creating it again", "group", b.group)
+		if err := b.createGroups(ctx); err != nil {
+			return nil, err
+		}
+		streams, err = b.rdb.XReadGroup(ctx, read).Result()
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, queue.ErrNoTaskInQueue
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// streams are returned in the order they were requested
+	var deliveries []*delivery
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			payload, _ := msg.Values[streamPayloadField].(string)
+			deliveries = append(deliveries, &delivery{
+				channel: stream.Stream,
+				id:      msg.ID,
+				data:    []byte(payload),
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil, queue.ErrNoTaskInQueue
+	}
+	b.mu.Lock()
+	for _, d := range deliveries {
+		b.lastID[d.channel] = d.id
+	}
+	b.mu.Unlock()
+
+	if len(deliveries) > 1 {
+		b.mu.Lock()
+		b.pending = append(b.pending, deliveries[1:]...)
+		b.mu.Unlock()
+	}
+
+	return deliveries[0], nil
+}
+
+func (b *streamBroker) ack(ctx context.Context, d *delivery) error {
+	if b.acks != nil {
+		return b.acks.add(ctx, d.channel, d.id)
+	}
+	return b.rdb.XAck(ctx, d.channel, b.group, d.id).Err()
+}
+
+func (b *streamBroker) ackPipe(ctx context.Context, pipe redis.Pipeliner, d *delivery) {
+	pipe.XAck(ctx, d.channel, b.group, d.id)
+}
+
+// reject acknowledges the entry so it is not delivered again and moves
+// it to the dead letter queue, the one of the broadcast channel for the
+// entries of retry streams.
+func (b *streamBroker) reject(ctx context.Context, d *delivery) error {
+	_, err := b.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, d.channel, b.group, d.id)
+		deadLetter(ctx, pipe, broadcastChannel(d.channel, b.consumer), d.data)
+		return nil
+	})
+	return err
+}
+
+// requeue acknowledges the entry and adds its message to the stream again,
+// since entries cannot be handed back to the group. Broadcast messages are
+// added to the retry stream of the consumer instead, not to run again on
+// every worker.
+func (b *streamBroker) requeue(ctx context.Context, d *delivery, data []byte) error {
+	channel := d.channel
+	if b.broadcast {
+		channel = broadcastRetryKey(channel, b.consumer)
+	}
+	_, err := b.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, d.channel, b.group, d.id)
+		_ = b.push(ctx, pipe, channel, data)
+		return nil
+	})
+	return err
+}
+
+// touch claims the entry again for the same consumer, which resets its
+// idle time in the pending entries list.
+func (b *streamBroker) touch(ctx context.Context, d *delivery) error {
+	return b.rdb.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   d.channel,
+		Group:    b.group,
+		Consumer: b.consumer,
+		Messages: []string{d.id},
+	}).Err()
+}
+
+// close sends the pending acks and requeues the entries read but not
+// handed out yet, so that they do not wait in the pending list of a
+// consumer that is gone.
+func (b *streamBroker) close() error {
+	close(b.stop)
+	b.wg.Wait()
+	if b.acks != nil {
+		b.acks.flush()
+	}
+
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	var errs []error
+	for _, d := range pending {
+		errs = append(errs, b.requeue(context.Background(), d, d.data))
+	}
+	return errors.Join(errs...)
+}
+
+// defaultConsumerName identifies this process within a consumer group.
+func defaultConsumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "redisdb"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// isReplyError reports whether err is an error reply of the server, such
+// as the one returned when a stream or consumer group does not exist.
+func isReplyError(err error) bool {
+	var reply redis.Error
+	return errors.As(err, &reply)
+}
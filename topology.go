@@ -0,0 +1,100 @@
+package redisdb
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Topology describes the effective connection and delivery settings of a Worker.
+type Topology struct {
+	Mode       string   `json:"mode"`
+	Channels   []string `json:"channels"`
+	Patterns   []string `json:"patterns,omitempty"`
+	Group      string   `json:"group,omitempty"`
+	Consumer   string   `json:"consumer,omitempty"`
+	Server     string   `json:"server"`
+	Addrs      []string `json:"addrs"`
+	MasterName string   `json:"master_name,omitempty"`
+	DB         int      `json:"db"`
+	TLS        bool     `json:"tls"`
+	Codec      string   `json:"codec"`
+	Shards     int      `json:"shards,omitempty"`
+	Guarantee  string   `json:"guarantee,omitempty"`
+	Flavor     string   `json:"flavor"`
+	// Retry is how the worker retries failed jobs. How many times, and
+	// the default delays, are set on each job.
+	Retry RetryTopology `json:"retry"`
+}
+
+// RetryTopology describes the retry policy of a Worker.
+type RetryTopology struct {
+	// Backoff tells whether WithBackoff replaces the delays of the jobs.
+	Backoff bool   `json:"backoff"`
+	Jitter  string `json:"jitter"`
+	// DelayFunc tells whether WithRetryDelayFunc is set.
+	DelayFunc bool `json:"delay_func"`
+	// PanicDeadLetter tells whether jobs that panic are dead-lettered
+	// without retries, see WithPanicDeadLetter.
+	PanicDeadLetter bool `json:"panic_dead_letter"`
+}
+
+// String returns the topology as a single JSON record.
+func (t Topology) String() string {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func newTopology(opts options) Topology {
+	t := Topology{
+		Mode:      opts.mode.String(),
+		Channels:  opts.channels,
+		Patterns:  opts.channelPatterns,
+		Server:    "standalone",
+		Addrs:     strings.Split(opts.addr, ","),
+		DB:        opts.db,
+		TLS:       opts.tls != nil,
+		Codec:     opts.codec.Name(),
+		Shards:    opts.shards,
+		Guarantee: opts.guarantee.String(),
+		Flavor:    opts.flavor.String(),
+		Retry: RetryTopology{
+			Backoff:         opts.backoff != nil,
+			Jitter:          opts.jitter.String(),
+			DelayFunc:       opts.retryDelay != nil,
+			PanicDeadLetter: opts.panicDeadLetter,
+		},
+	}
+
+	switch opts.mode {
+	case Stream:
+		t.Group = opts.consumerGroup
+		t.Consumer = opts.consumerName
+	case List, Asynq, Sidekiq:
+		t.Consumer = opts.consumerName
+	case PubSub, Celery:
+	}
+
+	// same precedence as the client selection in newClient
+	switch {
+	case opts.sentinel:
+		t.Server = "sentinel"
+		t.MasterName = opts.masterName
+	case opts.cluster:
+		t.Server = "cluster"
+		t.DB = 0
+	case opts.connectionString != "":
+		// never log the credentials embedded in the connection string
+		if v, err := redis.ParseURL(opts.connectionString); err == nil {
+			t.Addrs = []string{v.Addr}
+			t.DB = v.DB
+			t.TLS = v.TLSConfig != nil
+		}
+	}
+
+	return t
+}
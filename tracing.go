@@ -0,0 +1,84 @@
+package redisdb
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracePropagator carries the trace context of producers to handlers in the
+// envelope of jobs.
+var tracePropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	return tp.Tracer(instrumentationName)
+}
+
+func messagingAttrs(channel string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKey.String("redis"),
+		semconv.MessagingDestinationName(channel),
+	}
+}
+
+// startPublishSpan starts the span of a job queued to channel. The trace
+// context of the span is returned to be stored with the job.
+func (w *Worker) startPublishSpan(ctx context.Context, channel, id string) (context.Context, trace.Span, map[string]string) {
+	ctx, span := w.tracer.Start(ctx, channel+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingAttrs(channel)...),
+		trace.WithAttributes(
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingMessageID(id),
+		),
+	)
+	if w.opts.tracerProvider == nil {
+		return ctx, span, nil
+	}
+
+	carrier := propagation.MapCarrier{}
+	tracePropagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return ctx, span, nil
+	}
+	return ctx, span, carrier
+}
+
+// startProcessSpan starts the span of a job run by the worker, as a child
+// of the span that queued it.
+func (w *Worker) startProcessSpan(ctx context.Context, channel string, d *delivery) (context.Context, trace.Span) {
+	attrs := messagingAttrs(channel)
+	attrs = append(attrs, semconv.MessagingOperationTypeDeliver)
+	if d != nil {
+		if w.opts.tracerProvider != nil {
+			ctx = tracePropagator.Extract(ctx, propagation.MapCarrier(d.env.Trace))
+		}
+		if d.env.ID != "" {
+			attrs = append(attrs, semconv.MessagingMessageID(d.env.ID))
+		}
+	}
+	return w.tracer.Start(ctx, channel+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan ends span, recording err.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,54 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-queue/queue/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracerProvider(t *testing.T) {
+	ctx := context.Background()
+	redisC, endpoint := setupRedisContainer(ctx, t)
+	defer testcontainers.CleanupContainer(t, redisC)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	w := NewWorker(
+		WithAddr(endpoint),
+		WithChannel("traced"),
+		WithDeliveryMode(List),
+		WithTracerProvider(tp),
+	)
+
+	m := job.NewMessage(mockMessage{Message: "foo"})
+	id, err := w.QueueWithID(ctx, &m)
+	require.NoError(t, err)
+	task, err := w.Fetch(ctx, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, w.Run(ctx, task))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	publish, process := spans[0], spans[1]
+	assert.Equal(t, "traced publish", publish.Name)
+	assert.Equal(t, trace.SpanKindProducer, publish.SpanKind)
+	assert.Equal(t, "traced process", process.Name)
+	assert.Equal(t, trace.SpanKindConsumer, process.SpanKind)
+	assert.Equal(t, publish.SpanContext.TraceID(), process.SpanContext.TraceID())
+	assert.Equal(t, publish.SpanContext.SpanID(), process.Parent.SpanID())
+	assert.Contains(t, process.Attributes, messagingAttrs("traced")[1])
+	for _, kv := range process.Attributes {
+		if kv.Key == "messaging.message.id" {
+			assert.Equal(t, id, kv.Value.AsString())
+		}
+	}
+	assert.NoError(t, w.Shutdown())
+}